@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -9,17 +8,34 @@ import (
 
 // activateCmd represents the activate command
 var activateCmd = &cobra.Command{
-	Use:     "activate",
-	Aliases: []string{"a"},
-	Short:   "Use kubeconfig generated using kubeconfig files from KSPATH for new shell sessions",
+	Use:               "activate",
+	Aliases:           []string{"a"},
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Use kubeconfig generated using kubeconfig files from KSPATH for new shell sessions",
 	Long: `This command will set KUBECONFIG=${HOME}/.ks/config for the all future shell sessions.
 
+With --session, each new shell will instead get its own session file under ${HOME}/.ks/sessions layered over
+${HOME}/.ks/config. The current context and namespace are then stored in the session file, so "ks switch" in one
+shell does not affect any others. Session files of shells that are no longer running are removed automatically.
+
 Use "ks deactivate" to undo.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Write the init.sh file that will modify KUBECONFIG
-		err := os.WriteFile(initPath, []byte(fmt.Sprintf("export KUBECONFIG=%s", masterConfigPath)), 0644)
-		handleFatalf(err, "Error writing %s: %v", initPath, err)
+		flagSession := getBoolFlag(cmd, "session")
+
+		// Write the activation scripts that will modify KUBECONFIG
+		err := writeActivationScripts(true, flagSession)
+		handleFatalf(err, "Error writing activation scripts: %v", err)
+
+		if flagSession {
+			err = os.MkdirAll(sessionsDir, 0755)
+			handleFatalf(err, "Error creating %s: %v", sessionsDir, err)
+			err = gcSessions()
+			handleFatalf(err, "Error removing stale sessions: %v", err)
+
+			infof("Activated in session mode. Each future shell session will have its own current context and namespace.")
+			return
+		}
 
 		infof("Activated. KUBECONFIG will be set to %s for future shell sessions.", masterConfigPath)
 	},
@@ -27,4 +43,5 @@ Use "ks deactivate" to undo.
 
 func init() {
 	rootCmd.AddCommand(activateCmd)
+	activateCmd.Flags().BoolP("session", "s", false, "Give each shell session its own current context and namespace")
 }
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// alias is a short name for a context, optionally combined with a namespace.
+type alias struct {
+	Context   string `json:"context"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// aliases holds all aliases keyed by name.
+type aliases map[string]*alias
+
+// aliasCmd represents the alias command
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage short names for contexts and namespaces",
+	Long: `Aliases are short names for a context, optionally combined with a namespace. "ks switch <alias>" switches to the
+context and namespace of the alias. Aliases are stored in ${HOME}/.ks/aliases.yaml, so the original kubeconfig files
+are never changed.
+`,
+}
+
+// aliasAddCmd represents the alias add command
+var aliasAddCmd = &cobra.Command{
+	Use:               "add <alias> <context>",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeAliasTarget,
+	Short:             "Add or update an alias",
+	Example:           "  ks alias add pay prod-eks -n payments  # \"ks switch pay\" now selects prod-eks and namespace payments",
+	Run: func(cmd *cobra.Command, args []string) {
+		argAlias, argContext := args[0], args[1]
+		flagNamespace := getStringFlag(cmd, "namespace")
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		// An alias with the name of a context would hide that context from ks switch
+		if _, exists := conf.Contexts[argAlias]; exists {
+			fatalf("A context already exists with the name %s", argAlias)
+		}
+
+		ctxName, err := resolveContext(conf, argContext, getBoolFlag(cmd, "exact"))
+		handleFatalf(err, "Error finding context: %v", err)
+
+		a, err := loadAliases()
+		handleFatalf(err, "Error loading aliases: %v", err)
+		_, updated := a[argAlias]
+		a[argAlias] = &alias{Context: ctxName, Namespace: flagNamespace}
+		err = a.save()
+		handleFatalf(err, "Error writing aliases: %v", err)
+
+		if updated {
+			infof("Updated alias %s for %s.", argAlias, a[argAlias])
+		} else {
+			infof("Added alias %s for %s.", argAlias, a[argAlias])
+		}
+	},
+}
+
+// aliasListCmd represents the alias list command
+var aliasListCmd = &cobra.Command{
+	Use:               "list",
+	Aliases:           []string{"ls"},
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "List aliases",
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := loadAliases()
+		handleFatalf(err, "Error loading aliases: %v", err)
+
+		if len(a) == 0 {
+			infof("No aliases defined.")
+			return
+		}
+
+		for _, name := range sortedKeys(a) {
+			infof("%s -> %s", name, a[name])
+		}
+	},
+}
+
+// aliasRmCmd represents the alias rm command
+var aliasRmCmd = &cobra.Command{
+	Use:               "rm <alias> [alias...]",
+	Aliases:           []string{"remove", "delete"},
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeAliases,
+	Short:             "Remove aliases",
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := loadAliases()
+		handleFatalf(err, "Error loading aliases: %v", err)
+
+		for _, name := range args {
+			if _, ok := a[name]; !ok {
+				fatalf("No alias exists with name %s", name)
+			}
+			delete(a, name)
+		}
+
+		err = a.save()
+		handleFatalf(err, "Error writing aliases: %v", err)
+		infof("Removed aliases %v.", args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasRmCmd)
+	aliasAddCmd.Flags().StringP("namespace", "n", "", "The namespace to switch to along with the context")
+	aliasAddCmd.Flags().Bool("exact", false, "Only accept the exact context name")
+	_ = aliasAddCmd.RegisterFlagCompletionFunc("namespace", completeNamespaces(false))
+}
+
+// String returns the context and namespace of the alias.
+func (a *alias) String() string {
+	if a.Namespace == "" {
+		return a.Context
+	}
+	return a.Context + ` (namespace: "` + a.Namespace + `")`
+}
+
+// loadAliases loads all aliases from file. No aliases are returned if the file does not exist.
+func loadAliases() (aliases, error) {
+	a := make(aliases)
+	if err := readYAMLFile(aliasesPath, &a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// save writes the aliases to file.
+func (a aliases) save() error {
+	return writeYAMLFile(aliasesPath, a)
+}
+
+// forContext returns the names of the aliases for the context with the given name, in alphabetical order.
+func (a aliases) forContext(ctxName string) []string {
+	names := make([]string, 0)
+	for _, name := range sortedKeys(a) {
+		if a[name].Context == ctxName {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// renameContext points aliases for the context with the given old name at the context with the given new name. It
+// returns true if any alias was changed.
+func (a aliases) renameContext(oldName, newName string) bool {
+	changed := false
+	for _, target := range a {
+		if target.Context == oldName {
+			target.Context = newName
+			changed = true
+		}
+	}
+	return changed
+}
+
+// lookupAlias returns the alias with the given name, or nil if there is none. Contexts take precedence over aliases,
+// so nil is also returned if a context has the given name.
+func lookupAlias(conf *api.Config, name string) (*alias, error) {
+	if _, ok := conf.Contexts[name]; ok {
+		return nil, nil
+	}
+
+	a, err := loadAliases()
+	if err != nil {
+		return nil, err
+	}
+	return a[name], nil
+}
+
+// resolveContextOrAlias returns the name of the context the given argument refers to, which is either an alias or a
+// unique prefix, substring or fuzzy match of a context name, along with the namespace selected by the alias, if any.
+// It exits if no such context exists, including when an alias refers to a context that has since been deleted.
+func resolveContextOrAlias(conf *api.Config, arg string, exact bool) (ctxName string, namespace string) {
+	target, err := lookupAlias(conf, arg)
+	handleFatalf(err, "Error loading aliases: %v", err)
+
+	if target == nil {
+		ctxName, err = resolveContext(conf, arg, exact)
+		handleFatalf(err, "Error finding context: %v", err)
+		return ctxName, ""
+	}
+
+	if _, ok := conf.Contexts[target.Context]; !ok {
+		fatalf(`No such context: %s. Alias %s refers to it. Use "ks alias add" to update it.`, target.Context, arg)
+	}
+	return target.Context, target.Namespace
+}
+
+// checkAliases warns about aliases for contexts that don't exist in the given config.
+func checkAliases(conf *api.Config) {
+	a, err := loadAliases()
+	if err != nil {
+		warnf("Error loading aliases: %v", err)
+		return
+	}
+
+	for _, name := range sortedKeys(a) {
+		if _, ok := conf.Contexts[a[name].Context]; !ok {
+			warnf(`Alias %s refers to context %s, which no longer exists. Use "ks alias add" to update it.`, name, a[name].Context)
+		}
+	}
+}
+
+// completeAliases completes alias names, leaving out aliases already given as arguments.
+func completeAliases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	a, err := loadAliases()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := make([]string, 0, len(a))
+	for _, name := range sortedKeys(a) {
+		if strings.HasPrefix(name, toComplete) && !contains(args, name) {
+			completions = append(completions, name+"\talias for "+a[name].String())
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSwitchTarget completes a context name or an alias for the first argument of ks switch.
+func completeSwitchTarget(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	contexts, directive := completeContexts(cmd, args, toComplete)
+	aliasNames, _ := completeAliases(cmd, args, toComplete)
+	return append(aliasNames, contexts...), directive
+}
+
+// completeAliasTarget completes the context of an alias, which is the second argument of ks alias add.
+func completeAliasTarget(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeContexts(cmd, nil, toComplete)
+}
+
+// contains returns true if the given string is in the given slice.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
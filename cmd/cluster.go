@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// clusterTimeout is how long to wait for a cluster to respond before giving up.
+const clusterTimeout = 5 * time.Second
+
+// clusterClient makes raw requests to the API server of a cluster. Only the few endpoints ks needs are used, so a full
+// clientset isn't worth the dependencies.
+type clusterClient struct {
+	server *url.URL
+	client *http.Client
+}
+
+// namespace is the part of a namespace object ks cares about.
+type namespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+}
+
+// namespaceList is a list of namespaces returned by the API server.
+type namespaceList struct {
+	Items []namespace `json:"items"`
+}
+
+// restConfigFor returns the client config for the context with the given name.
+func restConfigFor(conf *api.Config, ctxName string) (*rest.Config, error) {
+	if _, ok := conf.Contexts[ctxName]; !ok {
+		return nil, fmt.Errorf("no such context: %s", ctxName)
+	}
+
+	clientConf := clientcmd.NewNonInteractiveClientConfig(*conf, ctxName, &clientcmd.ConfigOverrides{}, nil)
+	restConf, err := clientConf.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error building client config for context %s: %v", ctxName, err)
+	}
+
+	return restConf, nil
+}
+
+// newClusterClient returns a client for the cluster described by the given config.
+func newClusterClient(restConf *rest.Config) (*clusterClient, error) {
+	server, _, err := rest.DefaultServerURL(restConf.Host, restConf.APIPath, schema.GroupVersion{}, true)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing server URL: %v", err)
+	}
+
+	client, err := rest.HTTPClientFor(restConf)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP client: %v", err)
+	}
+
+	return &clusterClient{server: server, client: client}, nil
+}
+
+// clusterClientFor returns a client for the cluster of the context with the given name.
+func clusterClientFor(conf *api.Config, ctxName string) (*clusterClient, error) {
+	restConf, err := restConfigFor(conf, ctxName)
+	if err != nil {
+		return nil, err
+	}
+	return newClusterClient(restConf)
+}
+
+// do sends a request with the given method to the given path and decodes the JSON response into out, unless out is
+// nil. Error responses are returned as API status errors so they can be checked with apierrors.IsNotFound and friends.
+func (c *clusterClient) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	u := *c.server
+	u.Path = u.Path + path
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Prefer the status returned by the API server, since it explains what went wrong
+		status := &metav1.Status{}
+		if json.Unmarshal(data, status) == nil && status.Kind == "Status" {
+			return apierrors.FromObject(status)
+		}
+		return apierrors.NewGenericServerResponse(resp.StatusCode, method, schema.GroupResource{}, "", string(data), 0, true)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err = json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// listNamespaces returns the names of all namespaces in the cluster.
+func (c *clusterClient) listNamespaces(ctx context.Context) ([]string, error) {
+	list := &namespaceList{}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/namespaces", nil, list); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(list.Items))
+	for i, ns := range list.Items {
+		names[i] = ns.Name
+	}
+	return names, nil
+}
+
+// namespaceExists returns true if a namespace with the given name exists in the cluster.
+func (c *clusterClient) namespaceExists(ctx context.Context, name string) (bool, error) {
+	err := c.do(ctx, http.MethodGet, "/api/v1/namespaces/"+url.PathEscape(name), nil, nil)
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsNotFound(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// createNamespace creates a namespace with the given name in the cluster.
+func (c *clusterClient) createNamespace(ctx context.Context, name string) error {
+	ns := &namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	return c.do(ctx, http.MethodPost, "/api/v1/namespaces", ns, nil)
+}
+
+// version returns the version of the cluster's API server.
+func (c *clusterClient) version(ctx context.Context) (*version.Info, error) {
+	info := &version.Info{}
+	if err := c.do(ctx, http.MethodGet, "/version", nil, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// readyz returns an error if the cluster's API server doesn't report itself as ready.
+func (c *clusterClient) readyz(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/readyz", nil, nil)
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// testKubeconfig returns a config with a single context "test" for the cluster served by the given server, with the
+// given namespace. The server's certificate is trusted unless withoutCA is true.
+func testKubeconfig(srv *httptest.Server, namespace string, withoutCA bool) *api.Config {
+	conf := api.NewConfig()
+	cluster := api.NewCluster()
+	cluster.Server = srv.URL
+	if !withoutCA {
+		cluster.CertificateAuthorityData = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	}
+	authInfo := api.NewAuthInfo()
+	authInfo.Token = "abc"
+	ctx := api.NewContext()
+	ctx.Cluster = "test-cluster"
+	ctx.AuthInfo = "test-user"
+	ctx.Namespace = namespace
+
+	conf.Clusters["test-cluster"] = cluster
+	conf.AuthInfos["test-user"] = authInfo
+	conf.Contexts["test"] = ctx
+	conf.CurrentContext = "test"
+	return conf
+}
+
+// captureMessages sends messages to a buffer for the rest of the test.
+func captureMessages(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	prev := messageOut
+	messageOut = buf
+	t.Cleanup(func() { messageOut = prev })
+	return buf
+}
+
+// writeStatus writes an API status error with the given code and reason, like the API server does.
+func writeStatus(w http.ResponseWriter, code int, reason metav1.StatusReason, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(&metav1.Status{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+		Status:   metav1.StatusFailure,
+		Code:     int32(code),
+		Reason:   reason,
+		Message:  message,
+	})
+}
+
+func TestFetchNamespaces(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v1/namespaces" {
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "not found")
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			writeStatus(w, http.StatusUnauthorized, metav1.StatusReasonUnauthorized, "Unauthorized")
+			return
+		}
+		_, _ = w.Write([]byte(`{"kind":"NamespaceList","items":[{"metadata":{"name":"kube-system"}},` +
+			`{"metadata":{"name":"default"}},{"metadata":{"name":"apps"}}]}`))
+	}))
+	defer srv.Close()
+
+	namespaces, err := fetchNamespaces(testKubeconfig(srv, "", false), "test", time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"apps", "default", "kube-system"}; !reflect.DeepEqual(namespaces, want) {
+		t.Errorf("got namespaces %v, want %v", namespaces, want)
+	}
+}
+
+func TestNsFallsBackToUsedNamespaces(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusForbidden, metav1.StatusReasonForbidden, "namespaces is forbidden")
+	}))
+	defer srv.Close()
+
+	// Another context for the same cluster and the history know about more namespaces
+	conf := testKubeconfig(srv, "apps", false)
+	other := api.NewContext()
+	other.Cluster = "test-cluster"
+	other.AuthInfo = "test-user"
+	other.Namespace = "monitoring"
+	conf.Contexts["other"] = other
+
+	dir := t.TempDir()
+	confPath := filepath.Join(dir, "config")
+	if err := clientcmd.WriteToFile(*conf, confPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", confPath)
+
+	prevHistoryPath := historyPath
+	historyPath = filepath.Join(dir, "history.yaml")
+	t.Cleanup(func() { historyPath = prevHistoryPath })
+	h := &history{Entries: []historyEntry{{Context: "test", Namespace: "batch"}}}
+	if err := writeYAMLFile(historyPath, h); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureMessages(t)
+	nsCmd.Run(nsCmd, nil)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) == 0 || !strings.Contains(lines[0], "Not allowed to list namespaces in context test") {
+		t.Fatalf("expected a warning about the missing permission, got:\n%s", out)
+	}
+	if want := []string{"apps (current)", "batch", "default", "monitoring"}; !reflect.DeepEqual(lines[1:], want) {
+		t.Errorf("got namespaces %q, want %q", lines[1:], want)
+	}
+}
+
+func TestVerifyNamespace(t *testing.T) {
+	tests := []struct {
+		name        string
+		exists      bool
+		wantCreated bool
+		wantOutput  string
+	}{
+		{
+			name:   "existing namespace",
+			exists: true,
+		},
+		{
+			name:        "missing namespace is created",
+			wantCreated: true,
+			wantOutput:  "Created namespace apps.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var created string
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/apps":
+					if !test.exists {
+						writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, `namespaces "apps" not found`)
+						return
+					}
+					_, _ = w.Write([]byte(`{"kind":"Namespace","metadata":{"name":"apps"}}`))
+				case r.Method == http.MethodPost && r.URL.Path == "/api/v1/namespaces":
+					ns := &namespace{}
+					if err := json.NewDecoder(r.Body).Decode(ns); err != nil {
+						writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+						return
+					}
+					created = ns.Name
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{}`))
+				default:
+					writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "not found")
+				}
+			}))
+			defer srv.Close()
+
+			out := captureMessages(t)
+			verifyNamespace(testKubeconfig(srv, "", false), "test", "apps", true)
+
+			if test.wantCreated && created != "apps" {
+				t.Errorf("expected namespace apps to be created, got %q", created)
+			} else if !test.wantCreated && created != "" {
+				t.Errorf("expected no namespace to be created, got %q", created)
+			}
+			if got := strings.TrimSpace(out.String()); got != test.wantOutput {
+				t.Errorf("got output %q, want %q", got, test.wantOutput)
+			}
+		})
+	}
+}
+
+func TestVerifyNamespaceUnreachable(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	conf := testKubeconfig(srv, "", false)
+	srv.Close()
+
+	// Even without --create, an unreachable cluster must not stop ks from switching namespaces
+	out := captureMessages(t)
+	verifyNamespace(conf, "test", "apps", false)
+
+	if got := out.String(); !strings.Contains(got, "Could not check whether namespace apps exists") {
+		t.Errorf("expected a warning, got %q", got)
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// namespaceCacheTTL is how long namespaces fetched for completion are reused before asking the cluster again.
+	namespaceCacheTTL = time.Minute
+	// completionTimeout is how long completion waits for a cluster before falling back to namespaces used before.
+	completionTimeout = time.Second
+)
+
+// namespaceCache holds namespaces fetched for completion, keyed by cluster name.
+type namespaceCache map[string]*namespaceCacheEntry
+
+// namespaceCacheEntry holds the namespaces of a single cluster.
+type namespaceCacheEntry struct {
+	Namespaces []string  `json:"namespaces"`
+	Time       time.Time `json:"time"`
+}
+
+// isCompletion returns true if the given command is the hidden command used by shells to request completions.
+func isCompletion(cmd *cobra.Command) bool {
+	return cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd
+}
+
+// completionConfig loads the kubeconfig used for completion. Completion has to be fast, so the kubeconfig files from
+// KSPATH are not merged again. Instead the files listed in KUBECONFIG are used, falling back to the master config.
+func completionConfig() *api.Config {
+	paths := splitKubeconfig(os.Getenv("KUBECONFIG"))
+	if len(paths) == 0 {
+		paths = splitKubeconfig(masterConfigPath)
+	}
+
+	conf, err := loadKubeconfig(paths)
+	if err != nil {
+		return api.NewConfig()
+	}
+	return conf
+}
+
+// completeContexts completes context names, leaving out contexts already given as arguments.
+func completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	conf := completionConfig()
+
+	given := make(map[string]bool)
+	for _, arg := range args {
+		given[arg] = true
+	}
+
+	completions := make([]string, 0, len(conf.Contexts))
+	for _, name := range sortedKeys(conf.Contexts) {
+		if given[name] || !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+
+		ctx := conf.Contexts[name]
+		description := ctx.Cluster
+		if ctx.Namespace != "" {
+			description += " (namespace: " + ctx.Namespace + ")"
+		}
+		completions = append(completions, name+"\t"+description)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFirstContext completes a context name for the first argument only.
+func completeFirstContext(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeContexts(cmd, args, toComplete)
+}
+
+// completeClusters completes cluster names.
+func completeClusters(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	conf := completionConfig()
+	return filterPrefix(sortedKeys(conf.Clusters), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeUsers completes user names.
+func completeUsers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	conf := completionConfig()
+	return filterPrefix(sortedKeys(conf.AuthInfos), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeShells completes the names of supported shells.
+func completeShells(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return filterPrefix(shellNames(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNamespaces returns a function that completes namespaces in the cluster of a context. The context is taken
+// from the --from flag if set, then from the first argument if ctxArg is true, and is the current context otherwise.
+func completeNamespaces(ctxArg bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		conf := completionConfig()
+
+		ctxName := conf.CurrentContext
+		if from := cmd.Flags().Lookup("from"); from != nil && from.Value.String() != "" {
+			ctxName = from.Value.String()
+		} else if ctxArg && len(args) > 0 {
+			ctxName = args[0]
+			if target, err := lookupAlias(conf, ctxName); err == nil && target != nil {
+				ctxName = target.Context
+			}
+		}
+		if name, err := resolveContext(conf, ctxName, false); err == nil {
+			ctxName = name
+		} else {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return filterPrefix(cachedNamespaces(conf, ctxName), toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// cachedNamespaces returns the namespaces in the cluster of the context with the given name, asking the cluster only
+// if the cached namespaces are too old. If the cluster doesn't answer quickly, the namespaces used before are returned.
+func cachedNamespaces(conf *api.Config, ctxName string) []string {
+	cluster := conf.Contexts[ctxName].Cluster
+
+	cache := make(namespaceCache)
+	if err := readYAMLFile(nsCachePath, &cache); err == nil {
+		if entry, ok := cache[cluster]; ok && time.Since(entry.Time) < namespaceCacheTTL {
+			return entry.Namespaces
+		}
+	}
+
+	namespaces, err := fetchNamespaces(conf, ctxName, completionTimeout)
+	if err != nil {
+		namespaces = usedNamespaces(conf, ctxName)
+	}
+
+	// Cache failures too, so an unreachable cluster doesn't slow down every completion
+	cache[cluster] = &namespaceCacheEntry{Namespaces: namespaces, Time: time.Now()}
+	_ = writeYAMLFile(nsCachePath, cache)
+
+	return namespaces
+}
+
+// filterPrefix returns the given strings that start with the given prefix.
+func filterPrefix(values []string, prefix string) []string {
+	filtered := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
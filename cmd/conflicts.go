@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// conflict describes a context, cluster or user that is defined in more than one kubeconfig file under KSPATH.
+type conflict struct {
+	// winner is the file whose definition ends up in the merged config.
+	winner  string
+	entries []conflictEntry
+}
+
+// conflictEntry describes a definition that lost to the winning definition of a conflict.
+type conflictEntry struct {
+	path string
+	// differs is true if the definition is different from the winning one.
+	differs bool
+	// renamedTo is the name the definition was given during the merge to avoid a collision, if any.
+	renamedTo string
+}
+
+// conflictsCmd represents the conflicts command
+var conflictsCmd = &cobra.Command{
+	Use:               "conflicts",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "List contexts, clusters and users defined in more than one file",
+	Long: `This command lists every context, cluster and user that is defined in more than one kubeconfig file under KSPATH,
+along with the file whose definition wins the merge and the files whose definitions are shadowed by it.
+
+Clusters and users that are defined differently under the same name are renamed during the merge rather than shadowed,
+so they are listed along with their new names.
+
+Use --fail to exit with code 1 if any context, cluster or user is shadowed by a different definition.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flagFail := getBoolFlag(cmd, "fail")
+
+		s, err := loadSettings()
+		handleFatalf(err, "Error loading settings: %v", err)
+
+		contexts, clusters, users, err := findConflicts(kubeconfigPaths, s)
+		handleFatalf(err, "Error loading config: %v", err)
+
+		if len(contexts) == 0 && len(clusters) == 0 && len(users) == 0 {
+			infof("No conflicts found.")
+			return
+		}
+
+		// Print conflicts by kind
+		shadowed := printConflicts("context", contexts)
+		shadowed = printConflicts("cluster", clusters) || shadowed
+		shadowed = printConflicts("user", users) || shadowed
+
+		if flagFail && shadowed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(conflictsCmd)
+	conflictsCmd.Flags().Bool("fail", false, "Exit with code 1 if any definitions are shadowed by different ones")
+}
+
+// findConflicts merges kubeconfig files from the given paths the same way they are merged into the master config and
+// returns all contexts, clusters and users that are defined in more than one file, keyed by name.
+func findConflicts(paths []string, s *settings) (contexts, clusters, users map[string]*conflict, err error) {
+	contexts = make(map[string]*conflict)
+	clusters = make(map[string]*conflict)
+	users = make(map[string]*conflict)
+
+	merged := api.NewConfig()
+	err = walkKubeconfigFiles(paths, func(path string, conf *api.Config) error {
+		// The master config contains everything, so it would conflict with all other files
+		if isMasterConfig(path) {
+			return nil
+		}
+
+		if _, err := applyNamingRules(conf, path, s); err != nil {
+			return err
+		}
+		if err := clientcmd.ResolveLocalPaths(conf); err != nil {
+			return err
+		}
+
+		clusterRenames, userRenames := resolveCollisions(merged, conf, sourceSuffix(path))
+
+		for name, newName := range clusterRenames {
+			addConflict(clusters, name, merged.Clusters[name].LocationOfOrigin, conflictEntry{
+				path:      path,
+				differs:   true,
+				renamedTo: newName,
+			})
+		}
+		for name, cluster := range conf.Clusters {
+			if existing, exists := merged.Clusters[name]; exists {
+				addConflict(clusters, name, existing.LocationOfOrigin, conflictEntry{
+					path:    path,
+					differs: !clustersEqual(existing, cluster),
+				})
+			}
+		}
+
+		for name, newName := range userRenames {
+			addConflict(users, name, merged.AuthInfos[name].LocationOfOrigin, conflictEntry{
+				path:      path,
+				differs:   true,
+				renamedTo: newName,
+			})
+		}
+		for name, authInfo := range conf.AuthInfos {
+			if existing, exists := merged.AuthInfos[name]; exists {
+				addConflict(users, name, existing.LocationOfOrigin, conflictEntry{
+					path:    path,
+					differs: !authInfosEqual(existing, authInfo),
+				})
+			}
+		}
+
+		for name, ctx := range conf.Contexts {
+			if existing, exists := merged.Contexts[name]; exists {
+				addConflict(contexts, name, existing.LocationOfOrigin, conflictEntry{
+					path:    path,
+					differs: !contextsEqual(existing, ctx),
+				})
+			}
+		}
+
+		mergeKubeconfig(merged, conf)
+		return nil
+	})
+
+	return contexts, clusters, users, err
+}
+
+// addConflict records the given losing definition in the conflict with the given name, creating it if necessary.
+func addConflict(conflicts map[string]*conflict, name, winner string, entry conflictEntry) {
+	c, ok := conflicts[name]
+	if !ok {
+		c = &conflict{winner: winner}
+		conflicts[name] = c
+	}
+	c.entries = append(c.entries, entry)
+}
+
+// printConflicts prints the given conflicts of the given kind. It returns true if any definition is shadowed by a
+// different one.
+func printConflicts(kind string, conflicts map[string]*conflict) bool {
+	shadowed := false
+	for _, name := range sortedKeys(conflicts) {
+		c := conflicts[name]
+		infof(`%s "%s"`, kind, name)
+		infof("  %s (winner)", c.winner)
+
+		for _, entry := range c.entries {
+			switch {
+			case entry.renamedTo != "":
+				infof(`  %s (differs, renamed to "%s")`, entry.path, entry.renamedTo)
+			case entry.differs:
+				shadowed = true
+				infof("  %s (shadowed, differs)", entry.path)
+			default:
+				infof("  %s (shadowed, identical)", entry.path)
+			}
+		}
+	}
+
+	return shadowed
+}
@@ -8,13 +8,16 @@ import (
 
 // currentCmd represents the current command
 var currentCmd = &cobra.Command{
-	Use:     "current",
-	Aliases: []string{"c"},
-	Short:   "Show the current context",
-	Long: `Print information about the current context
+	Use:               "current",
+	Aliases:           []string{"c"},
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Show the current context",
+	Long: `Print information about the current context. Protected contexts are marked with "(protected)".
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		flagVerbose := getBoolFlag(cmd, "verbose")
+		printer, err := getOutputPrinter(cmd)
+		handleFatalf(err, "Invalid --output: %v", err)
 
 		// Load kubeconfig from file
 		confPath := os.Getenv("KUBECONFIG")
@@ -22,7 +25,7 @@ var currentCmd = &cobra.Command{
 			fatalf("KUBECONFIG is not set. Please make sure KUBECONFIG is set correctly.")
 		}
 
-		conf, err := loadKubeconfig([]string{confPath})
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
 		handleFatalf(
 			err,
 			"Error loading config from %s: %v. Please make sure KUBECONFIG is set correctly.",
@@ -30,16 +33,30 @@ var currentCmd = &cobra.Command{
 			err,
 		)
 
+		if printer != nil {
+			if _, ok := conf.Contexts[conf.CurrentContext]; !ok {
+				fatalf("No current context")
+			}
+			err = printer.print([]contextInfo{newContextInfo(conf, conf.CurrentContext, loadOrigins())}, true)
+			handleFatalf(err, "Error printing context: %v", err)
+			return
+		}
+
 		if len(conf.Contexts) == 0 {
 			infof("No contexts found. Please make sure your KSPATH is set correctly.")
 		}
 
-		// Print current context
-		printCtx(conf.CurrentContext, conf.Contexts[conf.CurrentContext], flagVerbose)
+		// Print current context, making it obvious if it is protected
+		name := conf.CurrentContext
+		if contextProtected(name) {
+			name += " (protected)"
+		}
+		printCtx(name, conf.Contexts[conf.CurrentContext], flagVerbose)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(currentCmd)
 	currentCmd.Flags().BoolP("verbose", "v", false, "Print all context info")
+	addOutputFlag(currentCmd)
 }
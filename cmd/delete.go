@@ -4,20 +4,33 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
-	Use:     "delete <context> [context...]",
+	Use:     "delete [context...]",
 	Aliases: []string{"rm", "remove"},
-	Args:    cobra.MinimumNArgs(1),
-	Short:   "Delete contexts",
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Contexts can be selected by label instead
+		if getStringFlag(cmd, "selector") != "" {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: completeContexts,
+	Short:             "Delete contexts",
 	Long: `This command deletes the given contexts from the kubeconfig pointed to by the KUBECONFIG env var. If any of 
 the contexts being deleted are the current context, the current context will be set to empty.
+
+Labels of the deleted contexts are removed, and aliases pointing at them are reported so they can be updated.
+
+Contexts can be given by a unique prefix, substring or fuzzy match of their name unless --exact is used, or selected by
+their labels with -l.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Load kubeconfig from file
-		confPath := os.Getenv("KUBECONFIG")
+		confPath := kubeconfigTarget(os.Getenv("KUBECONFIG"))
 		if confPath == "" {
 			fatalf("KUBECONFIG is not set. Please make sure KUBECONFIG is set correctly.")
 		}
@@ -30,7 +43,9 @@ the contexts being deleted are the current context, the current context will be
 			err,
 		)
 
-		for _, name := range args {
+		names := resolveContextsAndSelector(cmd, conf, args)
+
+		for _, name := range names {
 			// Delete the context
 			delete(conf.Contexts, name)
 
@@ -45,10 +60,54 @@ the contexts being deleted are the current context, the current context will be
 		err = writeKubeconfig(confPath, conf)
 		handleFatalf(err, "Error writing config to %s: %v", confPath, err)
 
-		infof("Deleted contexts %v.", args)
+		// Remove the contexts from the current shell's session too
+		err = updateSession(func(session *api.Config) {
+			for _, name := range names {
+				delete(session.Contexts, name)
+				if session.CurrentContext == name {
+					session.CurrentContext = ""
+				}
+			}
+		})
+		handleFatalf(err, "Error updating session: %v", err)
+
+		// Make sure the deletions survive the next merge
+		updateOverlay(confPath, func(o *overlay) {
+			for _, name := range names {
+				o.recordDelete(name)
+			}
+		})
+
+		// Labels belong to the context, so a new context with the same name shouldn't inherit them
+		l, err := loadLabels()
+		handleFatalf(err, "Error loading labels: %v", err)
+		labelsChanged := false
+		for _, name := range names {
+			if _, ok := l[name]; ok {
+				delete(l, name)
+				labelsChanged = true
+			}
+		}
+		if labelsChanged {
+			err = l.save()
+			handleFatalf(err, "Error writing labels: %v", err)
+		}
+
+		// Aliases are left alone, since they may be pointed at a context of the same name again
+		a, err := loadAliases()
+		handleFatalf(err, "Error loading aliases: %v", err)
+		for _, name := range names {
+			for _, aliasName := range a.forContext(name) {
+				warnf(`Alias %s refers to deleted context %s. Use "ks alias add" to update it.`, aliasName, name)
+			}
+		}
+
+		infof("Deleted contexts %v.", names)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.Flags().Bool("exact", false, "Only accept exact context names")
+	deleteCmd.Flags().StringP("selector", "l", "", "Also delete contexts with matching labels (e.g. env=dev)")
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const envExample = `
+  eval "$(ks env)"               # use ks-managed config in the current bash or zsh session
+  eval "$(ks env --deactivate)"  # restore the previous KUBECONFIG in the current bash or zsh session
+  ks env --shell fish | source   # use ks-managed config in the current fish session
+`
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:               "env",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Print shell code that activates ks in the current shell session",
+	Long: `This command prints shell code that sets KUBECONFIG to point at ${HOME}/.ks/config when evaluated, so ks can be
+activated in the running shell without affecting future shell sessions. The previous value of KUBECONFIG is kept so
+it can be restored with --deactivate.
+
+The shell is detected from the SHELL env var unless specified with --shell.
+`,
+	Example: strings.TrimLeft(envExample, "\n"),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Anything other than shell code must go to stderr so it doesn't get evaluated
+		messageOut = os.Stderr
+		rootCmd.PersistentPreRun(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		flagShell := getStringFlag(cmd, "shell")
+		flagDeactivate := getBoolFlag(cmd, "deactivate")
+		flagSession := getBoolFlag(cmd, "session")
+
+		sh, err := getShell(flagShell)
+		handleFatalf(err, "Error generating shell code: %v", err)
+
+		if flagDeactivate {
+			fmt.Print(deactivationScript(sh))
+			return
+		}
+
+		fmt.Print(envActivationScript(sh, flagSession))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.Flags().String("shell", "", "The shell to generate code for ("+strings.Join(shellNames(), ", ")+")")
+	envCmd.Flags().BoolP("deactivate", "d", false, "Restore the value KUBECONFIG had before activation")
+	envCmd.Flags().BoolP("session", "s", false, "Give the shell session its own current context and namespace")
+	_ = envCmd.RegisterFlagCompletionFunc("shell", completeShells)
+}
+
+// envActivationScript returns code that activates ks in the running shell, remembering the previous value of
+// KUBECONFIG so it can be restored later.
+func envActivationScript(sh *shell, session bool) string {
+	var sb strings.Builder
+
+	// Only remember KUBECONFIG if it isn't already ours, so activating twice doesn't lose the original value. If it
+	// already points at the master config (e.g. after "ks activate"), there is nothing worth restoring.
+	if os.Getenv("KS_ACTIVE") == "" {
+		sb.WriteString(sh.export("KS_ACTIVE", "1") + "\n")
+		if previous, ok := os.LookupEnv("KUBECONFIG"); ok && !isMasterConfig(kubeconfigTarget(previous)) {
+			sb.WriteString(sh.export("KS_PREVIOUS_KUBECONFIG", sh.escape(previous)) + "\n")
+		}
+	}
+
+	if !session && os.Getenv("KS_SESSION") != "" {
+		sb.WriteString(sh.unset("KS_SESSION") + "\n")
+	}
+	sb.WriteString(sh.activationScript(session))
+
+	return sb.String()
+}
+
+// deactivationScript returns code that restores the value KUBECONFIG had before ks was activated in the running shell.
+func deactivationScript(sh *shell) string {
+	var sb strings.Builder
+
+	switch {
+	case os.Getenv("KS_ACTIVE") != "":
+		// Activated with "ks env", so we know what KUBECONFIG was before, unless it was already the master config
+		if previous, ok := os.LookupEnv("KS_PREVIOUS_KUBECONFIG"); ok && !isMasterConfig(kubeconfigTarget(previous)) {
+			sb.WriteString(sh.export("KUBECONFIG", sh.escape(previous)) + "\n")
+		} else {
+			sb.WriteString(sh.unset("KUBECONFIG") + "\n")
+		}
+		sb.WriteString(sh.unset("KS_PREVIOUS_KUBECONFIG") + "\n")
+		sb.WriteString(sh.unset("KS_ACTIVE") + "\n")
+
+	case isMasterConfig(kubeconfigTarget(os.Getenv("KUBECONFIG"))):
+		// Activated with "ks activate", so the best we can do is fall back to the default KUBECONFIG
+		sb.WriteString(sh.unset("KUBECONFIG") + "\n")
+
+	default:
+		warnf("ks is not active in this shell session.")
+		return ""
+	}
+
+	if os.Getenv("KS_SESSION") != "" {
+		sb.WriteString(sh.unset("KS_SESSION") + "\n")
+	}
+
+	return sb.String()
+}
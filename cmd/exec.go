@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const execExample = `
+  ks exec prod-eks -- kubectl get pods           # list pods in context "prod-eks"
+  ks exec prod-eks -n kube-system -- helm list   # list helm releases in namespace "kube-system" of "prod-eks"
+`
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec <context> -- <command> [args...]",
+	Short: "Run a command in a different context",
+	Long: `This command runs a single command against a different context without changing the current one. It writes a
+temporary kubeconfig containing only the given context, its cluster and its user, and runs the command with KUBECONFIG
+pointing at it. Input, output, signals and the exit code of the command are passed through, and the temporary
+kubeconfig is removed when the command exits.
+
+The context can be an alias, or a unique prefix, substring or fuzzy match of a context name unless --exact is used.
+Running commands in protected contexts asks for confirmation unless --yes is given.
+`,
+	Example: strings.TrimLeft(execExample, "\n"),
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Without "--", flags meant for the command would be taken as our own
+		switch dash := cmd.ArgsLenAtDash(); {
+		case dash == -1:
+			return errors.New(`requires "--" between the context and the command`)
+		case dash != 1:
+			return errors.New(`requires exactly one context before "--"`)
+		case len(args) < 2:
+			return errors.New(`requires a command after "--"`)
+		}
+		return nil
+	},
+	ValidArgsFunction: completeExec,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Keep our own messages out of the command's output
+		messageOut = os.Stderr
+		rootCmd.PersistentPreRun(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		flagNamespace := getStringFlag(cmd, "namespace")
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		ctxName, aliasNs := resolveContextOrAlias(conf, args[0], getBoolFlag(cmd, "exact"))
+		if flagNamespace == "" {
+			flagNamespace = aliasNs
+		}
+
+		if contextProtected(ctxName) {
+			guardProtected(ctxName, "run commands in it", getBoolFlag(cmd, "yes"))
+		}
+
+		// Build a kubeconfig with only what the context needs
+		conf.CurrentContext = ctxName
+		err = api.MinifyConfig(conf)
+		handleFatalf(err, "Error building config for context %s: %v", ctxName, err)
+		if flagNamespace != "" {
+			conf.Contexts[ctxName].Namespace = flagNamespace
+		}
+
+		tmp, err := os.CreateTemp("", "ks-exec-*.yaml")
+		handleFatalf(err, "Error creating temporary kubeconfig: %v", err)
+		tmp.Close()
+		tmpPath := tmp.Name()
+
+		err = writeKubeconfig(tmpPath, conf)
+		if err != nil {
+			os.Remove(tmpPath)
+			fatalf("Error writing temporary kubeconfig: %v", err)
+		}
+
+		code := runWithKubeconfig(tmpPath, args[1:])
+		os.Remove(tmpPath)
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().StringP("namespace", "n", "", "The namespace to use in the context")
+	execCmd.Flags().Bool("exact", false, "Only accept the exact context name")
+	execCmd.Flags().BoolP("yes", "y", false, "Run commands in protected contexts without asking for confirmation")
+	_ = execCmd.RegisterFlagCompletionFunc("namespace", completeNamespaces(true))
+
+	// Flags of the command are parsed before Args is checked, so point out the missing "--" here too
+	execCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		if cmd.ArgsLenAtDash() == -1 {
+			return fmt.Errorf(`%v (put "--" between the context and the command)`, err)
+		}
+		return err
+	})
+}
+
+// runWithKubeconfig runs the given command with KUBECONFIG set to the given path and any other given env vars,
+// passing through stdio and any signals ks receives. It returns the exit code of the command.
+func runWithKubeconfig(kubeconfig string, command []string, env ...string) int {
+	c := exec.Command(command[0], command[1:]...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	c.Env = append(append(os.Environ(), "KUBECONFIG="+kubeconfig), env...)
+
+	// Catch signals before starting the command, so none are missed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+
+	if err := c.Start(); err != nil {
+		errorf("Error running %s: %v", command[0], err)
+		return 127
+	}
+
+	go func() {
+		for sig := range signals {
+			_ = c.Process.Signal(sig)
+		}
+	}()
+
+	err := c.Wait()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		return exitErr.ExitCode()
+	case errors.As(err, &exitErr):
+		// Killed by a signal, so exit the way shells report it
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		fallthrough
+	default:
+		// Killed by a signal or something else went wrong
+		errorf("Error running %s: %v", command[0], err)
+		return 1
+	}
+}
+
+// completeExec completes the context of ks exec, leaving the command to the shell's default completion.
+func completeExec(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeSwitchTarget(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/cert"
+)
+
+// Default thresholds for warnings about expiring credentials and certificates
+const (
+	defaultExpiryWarning  = 30 * 24 * time.Hour
+	defaultExpiryCritical = 7 * 24 * time.Hour
+)
+
+// Statuses of credentials and certificates
+const (
+	expiryOK          = "ok"
+	expiryWarning     = "warning"
+	expiryCritical    = "critical"
+	expiryExpired     = "expired"
+	expiryNotYetValid = "not yet valid"
+)
+
+// Kinds of credentials and certificates
+const (
+	credentialClientCert = "client certificate"
+	credentialToken      = "token"
+	credentialIDToken    = "id token"
+	credentialCA         = "CA certificate"
+)
+
+const expiryExample = `
+  ks expiry                  # check the credentials and CA certificates of all contexts
+  ks expiry prod-eks         # only check what context "prod-eks" uses
+  ks expiry -l env=prod      # only check contexts labelled env=prod
+  ks expiry --warning 2160h  # warn about anything that expires within 90 days
+`
+
+// credential is a client certificate, bearer token or CA certificate with a limited lifetime.
+type credential struct {
+	// Kind is one of the credential* constants.
+	Kind string
+	// Owner is the user or cluster the credential belongs to, e.g. `user "admin"`.
+	Owner string
+	// Contexts lists the contexts that use the credential.
+	Contexts []string
+	// NotBefore is when the credential becomes valid. It is zero for tokens.
+	NotBefore time.Time
+	// NotAfter is when the credential expires.
+	NotAfter time.Time
+	// Err is set if the credential couldn't be read.
+	Err error
+}
+
+// jwtClaims holds the claims of a JWT that ks cares about.
+type jwtClaims struct {
+	Exp *int64 `json:"exp"`
+}
+
+// expiryCmd represents the expiry command
+var expiryCmd = &cobra.Command{
+	Use:               "expiry [context...]",
+	ValidArgsFunction: completeContexts,
+	Short:             "Show when client certificates, tokens and CA certificates expire",
+	Long: `This command lists when the client certificates, bearer tokens and cluster CA certificates used by the given
+contexts expire, or those of all contexts if none are given, soonest first. Certificates are read whether they are
+embedded in the kubeconfig or referenced as files. Tokens are only listed if they are JWTs with an expiry time, and
+OIDC id tokens are only listed if there is no refresh token to renew them.
+
+Anything that expires within the warning threshold (30 days by default) gets the status "warning", and within the
+critical threshold (7 days by default) the status "critical". Certificates that are not valid yet are listed as "not yet
+valid". "ks switch" warns about the credentials of the context it switches to in the same way. The thresholds can be
+changed in ${HOME}/.ks/settings.yaml:
+
+  expiry:
+    warning: 720h
+    critical: 168h
+
+The exit code is 1 if anything is expired, critical or not yet valid. Contexts can be given by a unique prefix,
+substring or fuzzy match of their name unless --exact is used, or selected by their labels with -l.
+`,
+	Example: strings.TrimLeft(expiryExample, "\n"),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := loadSettings()
+		handleFatalf(err, "Error loading settings: %v", err)
+		warning, critical := s.expiryThresholds()
+		if cmd.Flags().Changed("warning") {
+			warning, err = cmd.Flags().GetDuration("warning")
+			handleFatalf(err, "Error reading flag warning: %v", err)
+		}
+		if cmd.Flags().Changed("critical") {
+			critical, err = cmd.Flags().GetDuration("critical")
+			handleFatalf(err, "Error reading flag critical: %v", err)
+		}
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		names := sortedKeys(conf.Contexts)
+		if len(args) > 0 || getStringFlag(cmd, "selector") != "" {
+			names = resolveContextsAndSelector(cmd, conf, args)
+		}
+
+		credentials := findCredentials(conf, names)
+		if len(credentials) == 0 {
+			infof("No certificates or tokens with an expiry time found.")
+			return
+		}
+
+		now := time.Now()
+		failed := false
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tEXPIRES\tIN\tKIND\tOWNER\tCONTEXTS")
+		for _, c := range credentials {
+			if c.Err != nil {
+				continue
+			}
+			status := c.status(now, warning, critical)
+			failed = failed || status == expiryExpired || status == expiryCritical || status == expiryNotYetValid
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", status, c.NotAfter.Local().Format("2006-01-02 15:04"),
+				formatRemaining(c.NotAfter.Sub(now)), c.Kind, c.Owner, strings.Join(c.Contexts, ", "))
+		}
+		err = w.Flush()
+		handleFatalf(err, "Error printing results: %v", err)
+
+		for _, c := range credentials {
+			if c.Err != nil {
+				warnf("Error reading %s of %s: %v", c.Kind, c.Owner, c.Err)
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(expiryCmd)
+	expiryCmd.Flags().Duration("warning", defaultExpiryWarning, "Warn about anything expiring within this time")
+	expiryCmd.Flags().Duration("critical", defaultExpiryCritical, "Treat anything expiring within this time as critical")
+	expiryCmd.Flags().Bool("exact", false, "Only accept exact context names")
+	expiryCmd.Flags().StringP("selector", "l", "", "Also check contexts with matching labels (e.g. env=dev)")
+}
+
+// findCredentials returns the credentials and CA certificates used by the contexts with the given names, soonest to
+// expire first. Credentials that couldn't be read come last.
+func findCredentials(conf *api.Config, ctxNames []string) []*credential {
+	// Find out which contexts use each user and cluster
+	userContexts := make(map[string][]string)
+	clusterContexts := make(map[string][]string)
+	for _, name := range ctxNames {
+		ctx := conf.Contexts[name]
+		if _, ok := conf.AuthInfos[ctx.AuthInfo]; ok {
+			userContexts[ctx.AuthInfo] = append(userContexts[ctx.AuthInfo], name)
+		}
+		if _, ok := conf.Clusters[ctx.Cluster]; ok {
+			clusterContexts[ctx.Cluster] = append(clusterContexts[ctx.Cluster], name)
+		}
+	}
+
+	credentials := make([]*credential, 0)
+	for _, name := range sortedKeys(userContexts) {
+		authInfo := conf.AuthInfos[name]
+		owner := fmt.Sprintf(`user "%s"`, name)
+
+		if authInfo.ClientCertificate != "" || len(authInfo.ClientCertificateData) > 0 {
+			c := &credential{Kind: credentialClientCert, Owner: owner, Contexts: userContexts[name]}
+			certs, err := readCerts(authInfo.ClientCertificate, authInfo.ClientCertificateData)
+			if err != nil {
+				c.Err = err
+			} else {
+				// The first certificate is the client's own, any others are intermediates
+				c.NotBefore, c.NotAfter = certs[0].NotBefore, certs[0].NotAfter
+			}
+			credentials = append(credentials, c)
+		}
+
+		if authInfo.Token != "" || authInfo.TokenFile != "" {
+			token, err := readToken(authInfo.Token, authInfo.TokenFile)
+			if err != nil {
+				credentials = append(credentials, &credential{
+					Kind: credentialToken, Owner: owner, Contexts: userContexts[name], Err: err,
+				})
+			} else if exp, ok := jwtExpiry(token); ok {
+				credentials = append(credentials, &credential{
+					Kind: credentialToken, Owner: owner, Contexts: userContexts[name], NotAfter: exp,
+				})
+			}
+		}
+
+		// OIDC id tokens are short-lived by design, so they only matter if they can't be refreshed
+		if p := authInfo.AuthProvider; p != nil && p.Config["id-token"] != "" && p.Config["refresh-token"] == "" {
+			if exp, ok := jwtExpiry(p.Config["id-token"]); ok {
+				credentials = append(credentials, &credential{
+					Kind: credentialIDToken, Owner: owner, Contexts: userContexts[name], NotAfter: exp,
+				})
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(clusterContexts) {
+		cluster := conf.Clusters[name]
+		hasCA := cluster.CertificateAuthority != "" || len(cluster.CertificateAuthorityData) > 0
+		if !hasCA || cluster.InsecureSkipTLSVerify {
+			continue
+		}
+
+		c := &credential{Kind: credentialCA, Owner: fmt.Sprintf(`cluster "%s"`, name), Contexts: clusterContexts[name]}
+		certs, err := readCerts(cluster.CertificateAuthority, cluster.CertificateAuthorityData)
+		if err != nil {
+			c.Err = err
+		} else {
+			// A bundle is only fully valid while all of its certificates are
+			for _, ca := range certs {
+				if c.NotAfter.IsZero() || ca.NotAfter.Before(c.NotAfter) {
+					c.NotAfter = ca.NotAfter
+				}
+				if ca.NotBefore.After(c.NotBefore) {
+					c.NotBefore = ca.NotBefore
+				}
+			}
+		}
+		credentials = append(credentials, c)
+	}
+
+	sort.SliceStable(credentials, func(i, j int) bool {
+		a, b := credentials[i], credentials[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		return a.NotAfter.Before(b.NotAfter)
+	})
+	return credentials
+}
+
+// readCerts parses the PEM-encoded certificates in the given data, or in the file at the given path if data is empty.
+func readCerts(path string, data []byte) ([]*x509.Certificate, error) {
+	if len(data) == 0 {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return cert.ParseCertsPEM(data)
+}
+
+// readToken returns the given token, or the contents of the file at the given path if it is empty.
+func readToken(token, path string) (string, error) {
+	if token != "" {
+		return token, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// jwtExpiry returns the expiry time of the given token. ok is false if it isn't a JWT or has no expiry time. The
+// signature isn't checked, since only the API server can do that.
+func jwtExpiry(token string) (exp time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	claims := &jwtClaims{}
+	if err = json.Unmarshal(payload, claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(*claims.Exp, 0), true
+}
+
+// status returns the status of the credential at the given time, given the warning and critical thresholds.
+func (c *credential) status(now time.Time, warning, critical time.Duration) string {
+	remaining := c.NotAfter.Sub(now)
+	switch {
+	case remaining <= 0:
+		return expiryExpired
+	case now.Before(c.NotBefore):
+		return expiryNotYetValid
+	case remaining <= critical:
+		return expiryCritical
+	case remaining <= warning:
+		return expiryWarning
+	default:
+		return expiryOK
+	}
+}
+
+// formatRemaining formats the given time until expiry in the largest whole unit, e.g. "12d", "5h" or "3d ago".
+func formatRemaining(d time.Duration) string {
+	suffix := ""
+	if d < 0 {
+		d, suffix = -d, " ago"
+	}
+
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd%s", d/(24*time.Hour), suffix)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%s", d/time.Hour, suffix)
+	default:
+		return fmt.Sprintf("%dm%s", d/time.Minute, suffix)
+	}
+}
+
+// warnExpiring warns about credentials and CA certificates of the context with the given name that are expired or
+// about to expire. Credentials that can't be read are left for ks expiry to report.
+func warnExpiring(conf *api.Config, ctxName string) {
+	s, err := loadSettings()
+	if err != nil {
+		warnf("Error loading settings: %v", err)
+		return
+	}
+	warning, critical := s.expiryThresholds()
+
+	now := time.Now()
+	for _, c := range findCredentials(conf, []string{ctxName}) {
+		if c.Err != nil {
+			continue
+		}
+
+		switch c.status(now, warning, critical) {
+		case expiryExpired:
+			warnf("The %s of %s expired %s (%s).", c.Kind, c.Owner, formatRemaining(c.NotAfter.Sub(now)),
+				c.NotAfter.Local().Format("2006-01-02 15:04"))
+		case expiryNotYetValid:
+			warnf("The %s of %s is not valid until %s.", c.Kind, c.Owner, c.NotBefore.Local().Format("2006-01-02 15:04"))
+		case expiryCritical, expiryWarning:
+			warnf("The %s of %s expires in %s (%s).", c.Kind, c.Owner, formatRemaining(c.NotAfter.Sub(now)),
+				c.NotAfter.Local().Format("2006-01-02 15:04"))
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// testJWT returns an unsigned JWT with the given payload.
+func testJWT(payload string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+}
+
+// testCert returns a PEM-encoded self-signed certificate valid between the given times.
+func testCert(t *testing.T, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestJWTExpiry(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "with exp",
+			token:  testJWT(`{"sub":"admin","exp":1700000000}`),
+			want:   time.Unix(1700000000, 0),
+			wantOK: true,
+		},
+		{
+			name:   "padded payload",
+			token:  "e30." + base64.URLEncoding.EncodeToString([]byte(`{"exp":1700000000}`)) + ".sig",
+			want:   time.Unix(1700000000, 0),
+			wantOK: true,
+		},
+		{name: "without exp", token: testJWT(`{"sub":"admin"}`)},
+		{name: "not a JWT", token: "abc"},
+		{name: "too many parts", token: testJWT(`{"exp":1700000000}`) + ".extra"},
+		{name: "invalid base64", token: "e30.!!!.sig"},
+		{name: "invalid JSON", token: testJWT(`{"exp":`)},
+		{name: "exp of the wrong type", token: testJWT(`{"exp":"tomorrow"}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := jwtExpiry(test.token)
+			if ok != test.wantOK || !got.Equal(test.want) {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestCredentialStatus(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		warning   time.Duration
+		critical  time.Duration
+		want      string
+	}{
+		{name: "ok", notAfter: now.Add(60 * day), want: expiryOK},
+		{name: "warning", notAfter: now.Add(20 * day), want: expiryWarning},
+		{name: "critical", notAfter: now.Add(3 * day), want: expiryCritical},
+		{name: "on the critical threshold", notAfter: now.Add(7 * day), want: expiryCritical},
+		{name: "expired", notAfter: now.Add(-time.Minute), want: expiryExpired},
+		{name: "expired right now", notAfter: now, want: expiryExpired},
+		{name: "not yet valid", notBefore: now.Add(day), notAfter: now.Add(60 * day), want: expiryNotYetValid},
+		{name: "custom warning", notAfter: now.Add(60 * day), warning: 90 * day, want: expiryWarning},
+		{name: "custom critical", notAfter: now.Add(20 * day), critical: 30 * day, want: expiryCritical},
+		{name: "no warning", notAfter: now.Add(20 * day), warning: time.Hour, critical: time.Minute, want: expiryOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			warning, critical := defaultExpiryWarning, defaultExpiryCritical
+			if test.warning != 0 {
+				warning = test.warning
+			}
+			if test.critical != 0 {
+				critical = test.critical
+			}
+
+			c := &credential{NotBefore: test.notBefore, NotAfter: test.notAfter}
+			if got := c.status(now, warning, critical); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindCredentials(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	day := 24 * time.Hour
+	dir := t.TempDir()
+
+	clientCertPath := filepath.Join(dir, "client.crt")
+	if err := os.WriteFile(clientCertPath, testCert(t, now.Add(-day), now.Add(10*day)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The bundle is valid from the later start to the earlier end of its certificates
+	bundle := append(testCert(t, now.Add(-10*day), now.Add(300*day)), testCert(t, now.Add(-5*day), now.Add(200*day))...)
+
+	conf := api.NewConfig()
+	conf.AuthInfos["cert-user"] = &api.AuthInfo{ClientCertificate: clientCertPath}
+	conf.AuthInfos["token-user"] = &api.AuthInfo{Token: testJWT(`{"exp":` + formatUnix(now.Add(day)) + `}`)}
+	conf.AuthInfos["opaque-user"] = &api.AuthInfo{Token: "not-a-jwt"}
+	conf.AuthInfos["oidc-user"] = &api.AuthInfo{AuthProvider: &api.AuthProviderConfig{
+		Name:   "oidc",
+		Config: map[string]string{"id-token": testJWT(`{"exp":` + formatUnix(now.Add(time.Hour)) + `}`)},
+	}}
+	conf.AuthInfos["refreshed-user"] = &api.AuthInfo{AuthProvider: &api.AuthProviderConfig{
+		Name: "oidc",
+		Config: map[string]string{
+			"id-token":      testJWT(`{"exp":` + formatUnix(now.Add(time.Hour)) + `}`),
+			"refresh-token": "abc",
+		},
+	}}
+	conf.AuthInfos["missing-user"] = &api.AuthInfo{ClientCertificate: filepath.Join(dir, "missing.crt")}
+	conf.Clusters["bundle"] = &api.Cluster{CertificateAuthorityData: bundle}
+	conf.Clusters["insecure"] = &api.Cluster{CertificateAuthorityData: bundle, InsecureSkipTLSVerify: true}
+	conf.Clusters["plain"] = &api.Cluster{}
+
+	contexts := map[string][2]string{
+		"cert":      {"plain", "cert-user"},
+		"token":     {"plain", "token-user"},
+		"opaque":    {"plain", "opaque-user"},
+		"oidc":      {"insecure", "oidc-user"},
+		"refreshed": {"insecure", "refreshed-user"},
+		"missing":   {"plain", "missing-user"},
+		"ca":        {"bundle", "opaque-user"},
+	}
+	for name, ctx := range contexts {
+		conf.Contexts[name] = &api.Context{Cluster: ctx[0], AuthInfo: ctx[1]}
+	}
+
+	credentials := findCredentials(conf, sortedKeys(conf.Contexts))
+
+	// Soonest to expire first, unreadable credentials last
+	want := []struct {
+		kind      string
+		owner     string
+		notBefore time.Time
+		notAfter  time.Time
+		err       bool
+	}{
+		{kind: credentialIDToken, owner: `user "oidc-user"`, notAfter: now.Add(time.Hour)},
+		{kind: credentialToken, owner: `user "token-user"`, notAfter: now.Add(day)},
+		{kind: credentialClientCert, owner: `user "cert-user"`, notBefore: now.Add(-day), notAfter: now.Add(10 * day)},
+		{kind: credentialCA, owner: `cluster "bundle"`, notBefore: now.Add(-5 * day), notAfter: now.Add(200 * day)},
+		{kind: credentialClientCert, owner: `user "missing-user"`, err: true},
+	}
+	if len(credentials) != len(want) {
+		for _, c := range credentials {
+			t.Logf("%s of %s", c.Kind, c.Owner)
+		}
+		t.Fatalf("got %d credentials, want %d", len(credentials), len(want))
+	}
+	for i, w := range want {
+		c := credentials[i]
+		if c.Kind != w.kind || c.Owner != w.owner || (c.Err != nil) != w.err {
+			t.Errorf("credential %d: got %s of %s (error %v), want %s of %s", i, c.Kind, c.Owner, c.Err, w.kind,
+				w.owner)
+			continue
+		}
+		if !c.NotBefore.Equal(w.notBefore) || !c.NotAfter.Equal(w.notAfter) {
+			t.Errorf("credential %d: got validity %v to %v, want %v to %v", i, c.NotBefore, c.NotAfter, w.notBefore,
+				w.notAfter)
+		}
+	}
+}
+
+func TestExpiryThresholds(t *testing.T) {
+	tests := []struct {
+		name         string
+		settings     string
+		wantWarning  time.Duration
+		wantCritical time.Duration
+	}{
+		{name: "defaults", wantWarning: defaultExpiryWarning, wantCritical: defaultExpiryCritical},
+		{
+			name:         "custom",
+			settings:     "expiry:\n  warning: 2160h\n  critical: 72h\n",
+			wantWarning:  2160 * time.Hour,
+			wantCritical: 72 * time.Hour,
+		},
+		{
+			name:         "only critical",
+			settings:     "expiry:\n  critical: 24h\n",
+			wantWarning:  defaultExpiryWarning,
+			wantCritical: 24 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prevSettingsPath := settingsPath
+			settingsPath = filepath.Join(t.TempDir(), "settings.yaml")
+			t.Cleanup(func() { settingsPath = prevSettingsPath })
+			if test.settings != "" {
+				if err := os.WriteFile(settingsPath, []byte(test.settings), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s, err := loadSettings()
+			if err != nil {
+				t.Fatal(err)
+			}
+			warning, critical := s.expiryThresholds()
+			if warning != test.wantWarning || critical != test.wantCritical {
+				t.Errorf("got %v and %v, want %v and %v", warning, critical, test.wantWarning, test.wantCritical)
+			}
+		})
+	}
+}
+
+// formatUnix formats the given time as seconds since the epoch.
+func formatUnix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
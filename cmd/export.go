@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const exportExample = `
+  ks export prod-eks -o prod.yaml        # write context "prod-eks" with its cluster and user to prod.yaml
+  ks export -l team=payments > pay.yaml  # export all contexts labelled team=payments
+  ks export prod-eks --redact            # show the shape of "prod-eks" without any secrets
+`
+
+// redacted replaces secret values in redacted kubeconfigs.
+const redacted = "REDACTED"
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use: "export [context...]",
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Contexts can be selected by label instead
+		if getStringFlag(cmd, "selector") != "" {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: completeContexts,
+	Short:             "Export contexts to a self-contained kubeconfig",
+	Long: `This command writes a kubeconfig holding only the given contexts and the clusters and users they refer to, e.g. to
+hand it to a colleague or a CI system. The first context becomes the current context. The kubeconfig is written to
+stdout unless -o is given.
+
+Certificate and key files are inlined as *-data fields, so the result doesn't depend on any other files. Use
+--flatten=false to keep the file references instead. With --redact, tokens, passwords, keys and other secrets are
+replaced, so only the shape of the config is shared.
+
+Contexts can be given by a unique prefix, substring or fuzzy match of their name unless --exact is used, or selected by
+their labels with -l.
+`,
+	Example: strings.TrimLeft(exportExample, "\n"),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Keep our own messages out of the exported config
+		messageOut = os.Stderr
+		rootCmd.PersistentPreRun(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		flagOutput := getStringFlag(cmd, "output")
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		names := resolveContextsAndSelector(cmd, conf, args)
+
+		exported, err := exportContexts(conf, names)
+		handleFatalf(err, "Error exporting contexts: %v", err)
+
+		if getBoolFlag(cmd, "flatten") {
+			err = api.FlattenConfig(exported)
+			handleFatalf(err, "Error inlining certificates and keys: %v", err)
+		}
+		if getBoolFlag(cmd, "redact") {
+			err = redactKubeconfig(exported)
+			handleFatalf(err, "Error removing secrets: %v", err)
+		}
+
+		if flagOutput == "" || flagOutput == "-" {
+			output, err := encodeKubeconfig(exported)
+			handleFatalf(err, "Error encoding config: %v", err)
+			_, err = os.Stdout.Write(output)
+			handleFatalf(err, "Error writing config: %v", err)
+			return
+		}
+
+		err = writeKubeconfig(flagOutput, exported)
+		handleFatalf(err, "Error writing config to %s: %v", flagOutput, err)
+		infof("Exported contexts %v to %s.", names, flagOutput)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringP("output", "o", "", "The file to write the kubeconfig to (default: stdout)")
+	exportCmd.Flags().Bool("flatten", true, "Inline certificate and key files as *-data fields")
+	exportCmd.Flags().Bool("redact", false, "Replace secrets so only the shape of the config is shared")
+	exportCmd.Flags().Bool("exact", false, "Only accept exact context names")
+	exportCmd.Flags().StringP("selector", "l", "", "Also export contexts with matching labels (e.g. env=dev)")
+}
+
+// exportContexts returns a new kubeconfig holding only the contexts with the given names and the clusters and users
+// they refer to. The first of the contexts is the current context.
+func exportContexts(conf *api.Config, names []string) (*api.Config, error) {
+	exported := api.NewConfig()
+	for _, name := range names {
+		minified := conf.DeepCopy()
+		minified.CurrentContext = name
+		if err := api.MinifyConfig(minified); err != nil {
+			return nil, fmt.Errorf("context %s: %v", name, err)
+		}
+		mergeKubeconfig(exported, minified)
+	}
+	return exported, nil
+}
+
+// redactKubeconfig replaces all secrets in the given kubeconfig, leaving only its shape.
+func redactKubeconfig(conf *api.Config) error {
+	if err := api.RedactSecrets(conf); err != nil {
+		return err
+	}
+	// Inlined certificates aren't secret, but they're noise when only the shape matters
+	api.ShortenConfig(conf)
+
+	// Auth providers and exec plugins can carry secrets in fields client-go doesn't know about
+	for _, authInfo := range conf.AuthInfos {
+		if authInfo.AuthProvider != nil {
+			for key := range authInfo.AuthProvider.Config {
+				authInfo.AuthProvider.Config[key] = redacted
+			}
+		}
+		if authInfo.Exec != nil {
+			for i := range authInfo.Exec.Env {
+				authInfo.Exec.Env[i].Value = redacted
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+)
+
+// matchTier describes how closely a query matches a candidate. Higher tiers are better matches.
+type matchTier int
+
+const (
+	noMatch matchTier = iota
+	fuzzyMatch
+	substringMatch
+	prefixMatch
+	exactMatch
+)
+
+// fuzzyMatchScore returns how closely the given query matches the given candidate, ignoring case. Within a tier,
+// candidates with higher scores are better matches.
+func fuzzyMatchScore(query, candidate string) (matchTier, int) {
+	q, c := []rune(strings.ToLower(query)), []rune(strings.ToLower(candidate))
+
+	switch {
+	case string(q) == string(c):
+		return exactMatch, 0
+	case strings.HasPrefix(string(c), string(q)):
+		return prefixMatch, -len(c)
+	case strings.Contains(string(c), string(q)):
+		return substringMatch, -strings.Index(string(c), string(q)) - len(c)
+	}
+
+	// Check if the query is a subsequence of the candidate, preferring consecutive runs of matching characters
+	score, run, qi := 0, 0, 0
+	for ci := 0; ci < len(c) && qi < len(q); ci++ {
+		if c[ci] == q[qi] {
+			qi++
+			run++
+			score += 2 * run
+		} else {
+			run = 0
+			score--
+		}
+	}
+
+	if qi < len(q) {
+		return noMatch, 0
+	}
+	return fuzzyMatch, score
+}
+
+// fuzzyRank returns the candidates that match the given query, best matches first. All candidates are returned in
+// their original order if the query is empty.
+func fuzzyRank(query string, candidates []string) []string {
+	if query == "" {
+		return append([]string(nil), candidates...)
+	}
+
+	type match struct {
+		name  string
+		tier  matchTier
+		score int
+	}
+
+	matches := make([]match, 0, len(candidates))
+	for _, candidate := range candidates {
+		tier, score := fuzzyMatchScore(query, candidate)
+		if tier != noMatch {
+			matches = append(matches, match{candidate, tier, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].tier != matches[j].tier {
+			return matches[i].tier > matches[j].tier
+		}
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	ranked := make([]string, len(matches))
+	for i, m := range matches {
+		ranked[i] = m.name
+	}
+	return ranked
+}
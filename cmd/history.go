@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// historyMaxEntries is the maximum number of entries kept in the history file.
+const historyMaxEntries = 50
+
+// history records the contexts and namespaces selected by ks switch, most recent first.
+type history struct {
+	Entries []historyEntry `json:"entries,omitempty"`
+}
+
+// historyEntry is a single context and namespace selected by ks switch.
+type historyEntry struct {
+	Context   string    `json:"context"`
+	Namespace string    `json:"namespace,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:               "history",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "List recently used contexts",
+	Long: `This command lists the contexts and namespaces recently selected with "ks switch", most recent first. Use
+"ks switch @<n>" to go back to the entry numbered n, or "ks switch -" to go back to the previous one.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flagClear := getBoolFlag(cmd, "clear")
+
+		if flagClear {
+			err := os.RemoveAll(historyPath)
+			handleFatalf(err, "Error removing %s: %v", historyPath, err)
+			infof("History cleared.")
+			return
+		}
+
+		h, err := loadHistory()
+		handleFatalf(err, "Error loading history: %v", err)
+
+		if len(h.Entries) == 0 {
+			infof("No history yet.")
+			return
+		}
+
+		for i, entry := range h.Entries {
+			infof("@%-3d %s  %s", i+1, entry.Time.Local().Format("2006-01-02 15:04:05"), entry)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().Bool("clear", false, "Remove all entries")
+}
+
+// String returns the context and namespace of the entry.
+func (e historyEntry) String() string {
+	return fmt.Sprintf(`%s (namespace: "%s")`, e.Context, e.Namespace)
+}
+
+// loadHistory loads the history from file. An empty history is returned if the file does not exist.
+func loadHistory() (*history, error) {
+	h := &history{}
+	if err := readYAMLFile(historyPath, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// save writes the history to file, dropping the oldest entries if there are too many.
+func (h *history) save() error {
+	if len(h.Entries) > historyMaxEntries {
+		h.Entries = h.Entries[:historyMaxEntries]
+	}
+	return writeYAMLFile(historyPath, h)
+}
+
+// record adds the given context and namespace to the front of the history. If they are the same as the most recent
+// entry, only its time is updated.
+func (h *history) record(ctxName, namespace string) {
+	entry := historyEntry{Context: ctxName, Namespace: namespace, Time: time.Now()}
+	if len(h.Entries) > 0 && h.Entries[0].Context == ctxName && h.Entries[0].Namespace == namespace {
+		h.Entries[0] = entry
+		return
+	}
+	h.Entries = append([]historyEntry{entry}, h.Entries...)
+}
+
+// previous returns the most recent entry that differs from the given context and namespace, like "cd -".
+func (h *history) previous(ctxName, namespace string) (historyEntry, bool) {
+	for _, entry := range h.Entries {
+		if entry.Context != ctxName || entry.Namespace != namespace {
+			return entry, true
+		}
+	}
+	return historyEntry{}, false
+}
+
+// lookupHistory returns the history entry referred to by the given argument to ks switch, which is either "-" for the
+// previous entry or "@<n>" for the nth most recent entry. ok is false if the argument doesn't refer to the history.
+func lookupHistory(arg, currentCtxName, currentNs string) (entry historyEntry, ok bool, err error) {
+	var n int
+	if arg != "-" {
+		if !strings.HasPrefix(arg, "@") {
+			return historyEntry{}, false, nil
+		}
+		if n, err = strconv.Atoi(arg[1:]); err != nil || n < 1 {
+			return historyEntry{}, false, nil
+		}
+	}
+
+	h, err := loadHistory()
+	if err != nil {
+		return historyEntry{}, true, err
+	}
+
+	if n == 0 {
+		if entry, ok = h.previous(currentCtxName, currentNs); !ok {
+			return historyEntry{}, true, fmt.Errorf("no previous context")
+		}
+		return entry, true, nil
+	}
+
+	if n > len(h.Entries) {
+		return historyEntry{}, true, fmt.Errorf("no history entry @%d", n)
+	}
+	return h.Entries[n-1], true, nil
+}
+
+// recordSwitch adds a switch from the given previous context and namespace to a new one to the history. The previous
+// context is recorded first if it isn't there already, so that "ks switch -" works right after the first switch.
+func recordSwitch(prevCtxName, prevNs, ctxName, namespace string) error {
+	h, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	if prevCtxName != "" {
+		h.record(prevCtxName, prevNs)
+	}
+	h.record(ctxName, namespace)
+
+	return h.save()
+}
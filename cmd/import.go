@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const importExample = `
+  ks import ~/Downloads/kubeconfig.yaml          # import a downloaded kubeconfig
+  ks import --name staging < staging.yaml       # import a single context under the name "staging"
+  pbpaste | ks import - --into ~/clusters/team  # import YAML from the clipboard into ~/clusters/team
+`
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:               "import [file|-]",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeImport,
+	Short:             "Import a kubeconfig into KSPATH",
+	Long: `This command copies a kubeconfig, e.g. a downloaded file or YAML pasted into the terminal, into a new file in the
+first directory in KSPATH, or the directory given with --into. The kubeconfig is read from stdin if no file or "-" is
+given. Relative paths to certificate and key files are made absolute, so they keep working from the new location.
+
+Nothing is written if the kubeconfig isn't valid, or if any of its contexts, clusters or users have the same name as a
+different one that already exists. Use --name to rename what is imported: if there is only one context, cluster or user,
+it gets the given name, otherwise the name is used as a prefix.
+`,
+	Example: strings.TrimLeft(importExample, "\n"),
+	Run: func(cmd *cobra.Command, args []string) {
+		flagName := getStringFlag(cmd, "name")
+		flagInto := getStringFlag(cmd, "into")
+
+		imported, err := readImport(args)
+		handleFatalf(err, "Error reading kubeconfig: %v", err)
+		err = validateImport(imported)
+		handleFatalf(err, "Invalid kubeconfig: %v", err)
+
+		// Don't let the imported config change the current context on the next merge
+		imported.CurrentContext = ""
+		if flagName != "" {
+			renameImport(imported, flagName)
+		}
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		if collisions := importCollisions(conf, imported); len(collisions) > 0 {
+			fatalf("Names already in use: %s. Use --name to rename what is imported.", strings.Join(collisions, ", "))
+		}
+
+		dir := expandHome(flagInto)
+		if dir == "" {
+			dir = firstKSPathDir()
+			if dir == "" {
+				fatalf("KSPATH doesn't list any existing directory. Use --into to choose where to import to.")
+			}
+		} else if !underKSPath(dir) {
+			warnf("%s is not under KSPATH, so the imported contexts won't be available until it is added.", dir)
+		}
+
+		name := flagName
+		if name == "" {
+			name = sortedKeys(imported.Contexts)[0]
+		}
+		path, err := importPath(dir, name)
+		handleFatalf(err, "Error choosing file name: %v", err)
+		err = writeKubeconfig(path, imported)
+		handleFatalf(err, "Error writing config to %s: %v", path, err)
+		infof("Imported %s.", path)
+
+		// Merge right away, so we can show what the contexts are called after naming rules have been applied
+		syncMasterConfig(false)
+		available := make([]string, 0)
+		for ctxName, origin := range loadOrigins() {
+			if origin, err := filepath.Abs(origin); err == nil && origin == path {
+				available = append(available, ctxName)
+			}
+		}
+		if len(available) == 0 {
+			return
+		}
+
+		sort.Strings(available)
+		infof("New contexts:")
+		for _, ctxName := range available {
+			infof("  %s", ctxName)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().String("name", "", "The new name, or prefix if there are several, of imported contexts, clusters and users")
+	importCmd.Flags().String("into", "", "The directory to write the kubeconfig to (default: first directory in KSPATH)")
+	_ = importCmd.MarkFlagDirname("into")
+}
+
+// readImport loads the kubeconfig given by args, which is either a file or "-" for stdin. Stdin is read if args is
+// empty. Relative paths are resolved against the directory of the file, or the working directory for stdin.
+func readImport(args []string) (*api.Config, error) {
+	if len(args) > 0 && args[0] != "-" {
+		conf, err := clientcmd.LoadFromFile(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return conf, clientcmd.ResolveLocalPaths(conf)
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		infof("Paste the kubeconfig, then press Ctrl-D.")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// There is no file to resolve relative paths against, and once written under KSPATH they would be resolved against
+	// the wrong directory, so resolve them against the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error resolving relative paths: %v", err)
+	}
+	for _, cluster := range conf.Clusters {
+		if err = clientcmd.ResolvePaths(clientcmd.GetClusterFileReferences(cluster), wd); err != nil {
+			return nil, err
+		}
+	}
+	for _, authInfo := range conf.AuthInfos {
+		if err = clientcmd.ResolvePaths(clientcmd.GetAuthInfoFileReferences(authInfo), wd); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// validateImport returns an error if the given kubeconfig has no contexts or any of its contexts refer to clusters or
+// users that it doesn't define.
+func validateImport(conf *api.Config) error {
+	if len(conf.Contexts) == 0 {
+		return fmt.Errorf("no contexts found")
+	}
+
+	for _, name := range sortedKeys(conf.Contexts) {
+		ctx := conf.Contexts[name]
+		if _, ok := conf.Clusters[ctx.Cluster]; !ok {
+			return fmt.Errorf("context %s refers to cluster %s, which is not defined", name, ctx.Cluster)
+		}
+		if _, ok := conf.AuthInfos[ctx.AuthInfo]; !ok && ctx.AuthInfo != "" {
+			return fmt.Errorf("context %s refers to user %s, which is not defined", name, ctx.AuthInfo)
+		}
+	}
+	return nil
+}
+
+// renameImport renames the contexts, clusters and users in the given kubeconfig. A single context, cluster or user is
+// given the new name, while several are prefixed with it.
+func renameImport(conf *api.Config, name string) {
+	newName := func(count int, old string) string {
+		if count == 1 {
+			return name
+		}
+		return name + "-" + old
+	}
+
+	clusters := make(map[string]*api.Cluster, len(conf.Clusters))
+	for old, cluster := range conf.Clusters {
+		clusters[newName(len(conf.Clusters), old)] = cluster
+	}
+	authInfos := make(map[string]*api.AuthInfo, len(conf.AuthInfos))
+	for old, authInfo := range conf.AuthInfos {
+		authInfos[newName(len(conf.AuthInfos), old)] = authInfo
+	}
+	contexts := make(map[string]*api.Context, len(conf.Contexts))
+	for old, ctx := range conf.Contexts {
+		ctx.Cluster = newName(len(conf.Clusters), ctx.Cluster)
+		if ctx.AuthInfo != "" {
+			ctx.AuthInfo = newName(len(conf.AuthInfos), ctx.AuthInfo)
+		}
+		contexts[newName(len(conf.Contexts), old)] = ctx
+	}
+
+	conf.Clusters, conf.AuthInfos, conf.Contexts = clusters, authInfos, contexts
+}
+
+// importCollisions returns descriptions of the contexts, clusters and users in imported that have the same name as
+// entries in conf. Clusters and users with identical definitions are fine, since they would be merged anyway.
+func importCollisions(conf, imported *api.Config) []string {
+	collisions := make([]string, 0)
+	for _, name := range sortedKeys(imported.Contexts) {
+		if _, exists := conf.Contexts[name]; exists {
+			collisions = append(collisions, "context "+name)
+		}
+	}
+	for _, name := range sortedKeys(imported.Clusters) {
+		if existing, exists := conf.Clusters[name]; exists && !clustersEqual(existing, imported.Clusters[name]) {
+			collisions = append(collisions, "cluster "+name)
+		}
+	}
+	for _, name := range sortedKeys(imported.AuthInfos) {
+		if existing, exists := conf.AuthInfos[name]; exists && !authInfosEqual(existing, imported.AuthInfos[name]) {
+			collisions = append(collisions, "user "+name)
+		}
+	}
+	return collisions
+}
+
+// firstKSPathDir returns the first existing directory listed in KSPATH, or an empty string if there is none.
+func firstKSPathDir() string {
+	for _, path := range kubeconfigPaths {
+		path = expandHome(path)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// underKSPath returns true if the given path is listed in KSPATH or is under a directory that is.
+func underKSPath(path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range kubeconfigPaths {
+		entry, err := filepath.Abs(expandHome(entry))
+		if err != nil {
+			continue
+		}
+		if path == entry || strings.HasPrefix(path, entry+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// importPath returns the path of a new file in the given directory, named after the given context name.
+func importPath(dir, name string) (string, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return "", err
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+
+	base := strings.Trim(invalidSuffixChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if base == "" {
+		base = "imported"
+	}
+
+	candidate := filepath.Join(dir, base+".yaml")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return filepath.Abs(candidate)
+		} else if err != nil {
+			return "", err
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s-%d.yaml", base, i))
+	}
+}
+
+// completeImport completes the kubeconfig file to import.
+func completeImport(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
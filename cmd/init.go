@@ -9,18 +9,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
-const shellInitScript = `
-# Added by ks init. Do not edit.
-[[ -f ${HOME}/.ks/init.sh ]] && source ${HOME}/.ks/init.sh`
-
 // initCmd represents the init command
 var initCmd = &cobra.Command{
-	Use:     "init",
-	Aliases: []string{"i"},
-	Short:   "Initialize ks",
-	Long: `This command will create the ${HOME}/.ks directory and add initialization code to the user's rc file (.bashrc, .zshrc, config.fish).
+	Use:               "init",
+	Aliases:           []string{"i"},
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Initialize ks",
+	Long: `This command will create the ${HOME}/.ks directory and add initialization code to the user's rc file (.bashrc,
+.zshrc, .profile, .kshrc, config.fish, config.nu). The shell is detected from the SHELL env var unless specified with
+--shell. The initialization code is only added if it isn't already there, so it is safe to run this command again
+with --force.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		flagShell := getStringFlag(cmd, "shell")
+
 		if !getBoolFlag(cmd, "force") {
 			// Abort if the .ks directory already exists (i.e. if we're already initialized)
 			info, err := os.Stat(ksHomeDir)
@@ -33,32 +35,42 @@ var initCmd = &cobra.Command{
 		err := os.MkdirAll(ksHomeDir, 0755)
 		handleFatalf(err, "Error creating %s: %v", ksHomeDir, err)
 
-		// Get the user's default shell
-		shell := os.Getenv("SHELL")
-		shellBaseName := strings.TrimPrefix(filepath.Base(shell), "-")
-
-		// Determine the init file path for some common shells
-		shellInitFilePath := ""
-		switch shellBaseName {
-		case "bash":
-			shellInitFilePath = homeDir + "/.bashrc"
-		case "zsh":
-			shellInitFilePath = homeDir + "/.zshrc"
-		case "fish":
-			shellInitFilePath = homeDir + ".config/fish/config.fish"
-		default:
-			infof(`Unknown shell "%s". Changes would not be made automatically.`, shellBaseName)
+		// Determine the user's shell
+		sh, err := getShell(flagShell)
+		if err != nil {
+			infof(`%v. Changes would not be made automatically.`, err)
 			infof(`To initialize manually, place the following code at the bottom of your shell's equivalent of .bashrc.`)
-			fatalf(shellInitScript)
+			fatalf("\n%s\n%s", rcMarker, posixSnippet)
+		}
+
+		// Some shells fail to start if the activation script they source doesn't exist
+		if sh.requiresInit {
+			if _, err = os.Stat(sh.initScriptPath()); os.IsNotExist(err) {
+				err = os.WriteFile(sh.initScriptPath(), nil, 0644)
+				handleFatalf(err, "Error writing %s: %v", sh.initScriptPath(), err)
+			}
+		}
+
+		// Don't add the initialization code again if it's already there
+		shellInitFilePath := sh.rcPath()
+		contents, err := os.ReadFile(shellInitFilePath)
+		if err != nil && !os.IsNotExist(err) {
+			fatalf("Error reading %s: %v", shellInitFilePath, err)
+		}
+		if strings.Contains(string(contents), rcMarker) {
+			infof(`Initialized. %s already contains initialization code.`, shellInitFilePath)
+			return
 		}
 
-		// Open the shell init file
-		initFile, err := os.OpenFile(shellInitFilePath, os.O_APPEND|os.O_WRONLY, 0644)
+		// Open the shell init file, creating it if necessary
+		err = os.MkdirAll(filepath.Dir(shellInitFilePath), 0755)
+		handleFatalf(err, "Error creating %s: %v", filepath.Dir(shellInitFilePath), err)
+		initFile, err := os.OpenFile(shellInitFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		handleFatalf(err, "Error updating %s: %v", shellInitFilePath, err)
 		defer initFile.Close()
 
 		// Write some data to the file
-		_, err = fmt.Fprintln(initFile, shellInitScript)
+		_, err = fmt.Fprintf(initFile, "\n%s\n%s\n", rcMarker, sh.rcSnippet)
 		handleFatalf(err, "Error writing file %s: %v", shellInitFilePath, err)
 
 		infof(
@@ -71,4 +83,6 @@ var initCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(initCmd)
 	initCmd.Flags().BoolP("force", "f", false, "Force reinitialization")
+	initCmd.Flags().String("shell", "", "The shell to initialize ("+strings.Join(shellNames(), ", ")+")")
+	_ = initCmd.RegisterFlagCompletionFunc("shell", completeShells)
 }
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const labelExample = `
+  ks label prod-eks env=prod team=payments  # add labels to context "prod-eks"
+  ks label prod-eks env=production --overwrite
+  ks label prod-eks team-                   # remove the "team" label
+  ks label prod-eks                         # show the labels of context "prod-eks"
+  ks list -l env=prod,team!=infra           # list contexts by label
+`
+
+// contextLabels holds the labels of contexts, keyed by context name.
+type contextLabels map[string]map[string]string
+
+// labelCmd represents the label command
+var labelCmd = &cobra.Command{
+	Use:               "label <context> [key=value...] [key-...]",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeFirstContext,
+	Short:             "Update the labels of a context",
+	Long: `This command adds, changes or removes labels on a context. Labels are stored in ${HOME}/.ks/labels.yaml, so the
+original kubeconfig files are never changed. "key=value" sets a label and "key-" removes it. Changing the value of an
+existing label requires --overwrite. Without any changes, the labels of the context are shown.
+
+Labels use the same syntax as Kubernetes labels, and commands that accept several contexts can select them with -l,
+e.g. "ks list -l env=prod,team!=infra".
+`,
+	Example: strings.TrimLeft(labelExample, "\n"),
+	Run: func(cmd *cobra.Command, args []string) {
+		flagOverwrite := getBoolFlag(cmd, "overwrite")
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		ctxName, err := resolveContext(conf, args[0], getBoolFlag(cmd, "exact"))
+		handleFatalf(err, "Error finding context: %v", err)
+
+		l, err := loadLabels()
+		handleFatalf(err, "Error loading labels: %v", err)
+
+		if len(args) == 1 {
+			if len(l[ctxName]) == 0 {
+				infof("Context %s has no labels.", ctxName)
+				return
+			}
+			infof("%s", labels.Set(l[ctxName]))
+			return
+		}
+
+		current := labels.Set(l[ctxName])
+		updated := labels.Merge(current, nil)
+		for _, arg := range args[1:] {
+			if key, ok := strings.CutSuffix(arg, "-"); ok && !strings.Contains(arg, "=") {
+				delete(updated, key)
+				continue
+			}
+
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				fatalf(`Invalid label %s. Use "key=value" to set a label or "key-" to remove it.`, arg)
+			}
+			err = validateLabel(key, value)
+			handleFatalf(err, "Invalid label %s: %v", arg, err)
+
+			if old, exists := current[key]; exists && old != value && !flagOverwrite {
+				fatalf("Context %s already has label %s=%s. Use --overwrite to change it.", ctxName, key, old)
+			}
+			updated[key] = value
+		}
+
+		if len(updated) == 0 {
+			delete(l, ctxName)
+		} else {
+			l[ctxName] = updated
+		}
+		err = l.save()
+		handleFatalf(err, "Error writing labels: %v", err)
+
+		infof("Labels of context %s: %s", ctxName, updated)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(labelCmd)
+	labelCmd.Flags().Bool("overwrite", false, "Allow changing the value of existing labels")
+	labelCmd.Flags().Bool("exact", false, "Only accept the exact context name")
+}
+
+// validateLabel returns an error if the given key and value aren't valid for a Kubernetes label.
+func validateLabel(key, value string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid key: %s", strings.Join(errs, "; "))
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return fmt.Errorf("invalid value: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// loadLabels loads the labels of all contexts from file. No labels are returned if the file does not exist.
+func loadLabels() (contextLabels, error) {
+	l := make(contextLabels)
+	if err := readYAMLFile(labelsPath, &l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// save writes the labels to file.
+func (l contextLabels) save() error {
+	return writeYAMLFile(labelsPath, l)
+}
+
+// selectContexts returns the names of the contexts in the given config whose labels match the given selector, in
+// alphabetical order.
+func selectContexts(conf *api.Config, selector string) ([]string, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %s: %v", selector, err)
+	}
+
+	l, err := loadLabels()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, name := range sortedKeys(conf.Contexts) {
+		if sel.Matches(labels.Set(l[name])) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// resolveContextsAndSelector returns the names of the contexts given as arguments to the given command, followed by
+// those matching its -l selector that weren't given already. It exits if a context can't be found or if the selector
+// matches nothing.
+func resolveContextsAndSelector(cmd *cobra.Command, conf *api.Config, args []string) []string {
+	names, err := resolveContexts(conf, args, getBoolFlag(cmd, "exact"))
+	handleFatalf(err, "Error finding context: %v", err)
+
+	flagSelector := getStringFlag(cmd, "selector")
+	if flagSelector == "" {
+		return names
+	}
+
+	selected, err := selectContexts(conf, flagSelector)
+	handleFatalf(err, "Error selecting contexts: %v", err)
+	if len(selected) == 0 {
+		fatalf("No contexts match %s", flagSelector)
+	}
+	for _, name := range selected {
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
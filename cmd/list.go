@@ -3,45 +3,100 @@ package cmd
 import (
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
-	Use:     "list",
-	Aliases: []string{"l"},
-	Args:    cobra.ExactArgs(0),
-	Short:   "List available contexts",
-	Long: `List all contexts found in files or directories listed in $ks_PATH.
+	Use:               "list",
+	Aliases:           []string{"l"},
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "List available contexts",
+	Long: `List all contexts found in files or directories listed in $ks_PATH. Aliases are shown in brackets after the
+contexts they refer to. Use -l to only list contexts whose labels (see "ks label") match a selector. With --health, the
+cluster of every context is checked at the same time and its status is shown after the context (see "ks ping").
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		flagVerbose := getBoolFlag(cmd, "verbose")
+		flagSelector := getStringFlag(cmd, "selector")
+		flagHealth := getBoolFlag(cmd, "health")
+		printer, err := getOutputPrinter(cmd)
+		handleFatalf(err, "Invalid --output: %v", err)
 
 		// Load kubeconfig from file
 		confPath := os.Getenv("KUBECONFIG")
-		conf, err := loadKubeconfig([]string{confPath})
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
 		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
 
-		if len(conf.Contexts) == 0 {
+		if len(conf.Contexts) == 0 && printer == nil {
 			infof("No contexts found. Please make sure your KSPATH is set correctly.")
 		}
 
-		// Print current context first
-		printCtx(conf.CurrentContext+" (current)", conf.Contexts[conf.CurrentContext], flagVerbose)
+		// Only show contexts with matching labels if a selector was given
+		if flagSelector != "" {
+			names, err := selectContexts(conf, flagSelector)
+			handleFatalf(err, "Error selecting contexts: %v", err)
 
-		// Put remaining contexts in alphabetical order
+			selected := make(map[string]*api.Context, len(names))
+			for _, name := range names {
+				selected[name] = conf.Contexts[name]
+			}
+			conf.Contexts = selected
+		}
+
+		// Put current context first, followed by remaining contexts in alphabetical order
+		names := make([]string, 0, len(conf.Contexts))
+		if _, ok := conf.Contexts[conf.CurrentContext]; ok {
+			names = append(names, conf.CurrentContext)
+		}
 		others := make([]string, 0)
-		for name, _ := range conf.Contexts {
+		for name := range conf.Contexts {
 			if name != conf.CurrentContext {
 				others = append(others, name)
 			}
 		}
 		sort.Strings(others)
+		names = append(names, others...)
+
+		// Check the clusters of all listed contexts at once
+		var health []pingResult
+		if flagHealth {
+			health = pingContexts(conf, names, clusterTimeout)
+		}
 
-		// Print remaining contexts in order
-		for _, name := range others {
-			printCtx(name, conf.Contexts[name], flagVerbose)
+		if printer != nil {
+			origins := loadOrigins()
+			infos := make([]contextInfo, len(names))
+			for i, name := range names {
+				infos[i] = newContextInfo(conf, name, origins)
+				if flagHealth {
+					infos[i].Health = &health[i]
+				}
+			}
+			err = printer.print(infos, false)
+			handleFatalf(err, "Error printing contexts: %v", err)
+			return
+		}
+
+		// Show aliases next to the contexts they refer to
+		a, err := loadAliases()
+		handleFatalf(err, "Error loading aliases: %v", err)
+		for i, name := range names {
+			label := name
+			if aliasNames := a.forContext(name); len(aliasNames) > 0 {
+				label += " [" + strings.Join(aliasNames, ", ") + "]"
+			}
+			if name == conf.CurrentContext {
+				label += " (current)"
+			}
+			if flagHealth {
+				label += " [" + health[i].String() + "]"
+			}
+			printCtx(label, conf.Contexts[name], flagVerbose)
 		}
 	},
 }
@@ -49,4 +104,7 @@ var listCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().BoolP("verbose", "v", false, "Print all context info")
+	listCmd.Flags().StringP("selector", "l", "", "Only list contexts with matching labels (e.g. env=prod,team!=infra)")
+	listCmd.Flags().Bool("health", false, `Check the cluster of every context like "ks ping" does`)
+	addOutputFlag(listCmd)
 }
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// manifest records the state of every input to the last merge, so the merge can be skipped when nothing has changed.
+type manifest struct {
+	// KSPath is the value of KSPATH used for the merge.
+	KSPath string `json:"ksPath"`
+	// Kubeconfig is the kubeconfig file from KUBECONFIG used for the merge.
+	Kubeconfig string `json:"kubeconfig"`
+	// Sources holds fingerprints of all files found under KSPATH, including invalid ones.
+	Sources []fingerprint `json:"sources"`
+	// Extras holds fingerprints of other files that affect the result of the merge.
+	Extras []fingerprint `json:"extras"`
+}
+
+// fingerprint identifies the contents of a file at a point in time.
+type fingerprint struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Hash    string    `json:"hash"`
+	// Valid is true if the file is a valid kubeconfig file.
+	Valid bool `json:"valid,omitempty"`
+}
+
+// loadManifest loads the manifest from file. An empty manifest is returned if the file does not exist.
+func loadManifest() (*manifest, error) {
+	m := &manifest{}
+	if err := readYAMLFile(manifestPath, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// save writes the manifest to file.
+func (m *manifest) save() error {
+	return writeYAMLFile(manifestPath, m)
+}
+
+// recordOwnWrite updates the fingerprint of the file at the given path after ks has written it, so that ks's own changes
+// to its inputs, like switching contexts in the master config, don't force a full merge the next time it runs. Nothing
+// happens if the file isn't an input to the merge.
+func recordOwnWrite(path string) error {
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, fingerprints := range [][]fingerprint{m.Sources, m.Extras} {
+		for i := range fingerprints {
+			if filepath.Clean(fingerprints[i].Path) != filepath.Clean(path) {
+				continue
+			}
+			fp, err := fingerprintFile(fingerprints[i].Path, info, nil, fingerprints[i].Valid)
+			if err != nil {
+				return err
+			}
+			fingerprints[i] = fp
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return m.save()
+}
+
+// equal returns true if both manifests describe identical inputs.
+func (m *manifest) equal(other *manifest) bool {
+	if m.KSPath != other.KSPath || m.Kubeconfig != other.Kubeconfig {
+		return false
+	}
+	return fingerprintsEqual(m.Sources, other.Sources) && fingerprintsEqual(m.Extras, other.Extras)
+}
+
+// validSources returns the paths of all valid kubeconfig files in the manifest in order of precedence.
+func (m *manifest) validSources() []string {
+	paths := make([]string, 0, len(m.Sources))
+	for _, source := range m.Sources {
+		if source.Valid {
+			paths = append(paths, source.Path)
+		}
+	}
+	return paths
+}
+
+// fingerprintsEqual returns true if both lists contain the same fingerprints in the same order.
+func fingerprintsEqual(a, b []fingerprint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Path != b[i].Path || a[i].Size != b[i].Size || !a[i].ModTime.Equal(b[i].ModTime) || a[i].Hash != b[i].Hash {
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildManifest fingerprints all files under the given kubeconfig paths as well as the given extra files. Files are
+// only read and validated if their size or modification time differs from the previous manifest.
+func buildManifest(ksPath string, paths []string, extras []string, previous *manifest) (*manifest, error) {
+	// Index previous fingerprints so unchanged files don't need to be read again
+	known := make(map[string]fingerprint)
+	for _, fp := range append(previous.Sources, previous.Extras...) {
+		known[fp.Path] = fp
+	}
+
+	m := &manifest{
+		KSPath:     ksPath,
+		Kubeconfig: sharedKubeconfigTarget(),
+		Sources:    make([]fingerprint, 0),
+		Extras:     make([]fingerprint, 0),
+	}
+
+	for _, path := range paths {
+		// Find all files in path
+		if err := filepath.Walk(expandHome(path), func(currentPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			fp, err := fingerprintFile(currentPath, info, known, true)
+			if err != nil {
+				return err
+			}
+
+			m.Sources = append(m.Sources, fp)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range extras {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		fp, err := fingerprintFile(path, info, known, false)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Extras = append(m.Extras, fp)
+	}
+
+	return m, nil
+}
+
+// fingerprintFile returns the fingerprint of the given file, reusing the known fingerprint if the file's size and
+// modification time haven't changed. If validate is true, the file is also checked to be a valid kubeconfig file.
+func fingerprintFile(path string, info os.FileInfo, known map[string]fingerprint, validate bool) (fingerprint, error) {
+	fp := fingerprint{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+
+	if prev, ok := known[path]; ok && prev.Size == fp.Size && prev.ModTime.Equal(fp.ModTime) {
+		fp.Hash = prev.Hash
+		fp.Valid = prev.Valid
+		return fp, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fp, err
+	}
+
+	sum := sha256.Sum256(data)
+	fp.Hash = hex.EncodeToString(sum[:])
+
+	if validate {
+		if prev, ok := known[path]; ok && prev.Hash == fp.Hash {
+			// Only the modification time changed
+			fp.Valid = prev.Valid
+		} else {
+			// Check if this is a valid kubeconfig file by loading it
+			_, err = clientcmd.Load(data)
+			fp.Valid = err == nil
+		}
+	}
+
+	return fp, nil
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const testManifestKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+users:
+- name: admin
+  user:
+    token: abc
+`
+
+// writeTestFile writes the given contents to the given file, moving its modification time forward so changes are
+// noticed even on file systems with coarse timestamps.
+func writeTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if info, err := os.Stat(path); err == nil && !info.ModTime().Before(modTime) {
+		modTime = info.ModTime().Add(time.Minute)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildManifest(t *testing.T) {
+	tests := []struct {
+		name string
+		// change is made to the source directory and master config after the first manifest is built
+		change    func(t *testing.T, dir, master string)
+		ksPath    string
+		wantEqual bool
+	}{
+		{
+			name:      "nothing changed",
+			change:    func(t *testing.T, dir, master string) {},
+			wantEqual: true,
+		},
+		{
+			name: "source added",
+			change: func(t *testing.T, dir, master string) {
+				writeTestFile(t, filepath.Join(dir, "staging.yaml"), testManifestKubeconfig)
+			},
+		},
+		{
+			name: "source removed",
+			change: func(t *testing.T, dir, master string) {
+				if err := os.Remove(filepath.Join(dir, "prod.yaml")); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "source modified",
+			change: func(t *testing.T, dir, master string) {
+				writeTestFile(t, filepath.Join(dir, "prod.yaml"), testManifestKubeconfig+"current-context: prod\n")
+			},
+		},
+		{
+			name:   "KSPATH changed",
+			change: func(t *testing.T, dir, master string) {},
+			ksPath: "~/.kube:~/other",
+		},
+		{
+			name: "master config edited outside ks",
+			change: func(t *testing.T, dir, master string) {
+				writeTestFile(t, master, testManifestKubeconfig+"current-context: prod\n")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, filepath.Join(dir, "prod.yaml"), testManifestKubeconfig)
+			writeTestFile(t, filepath.Join(dir, "broken.yaml"), "not: [a kubeconfig")
+			master := filepath.Join(t.TempDir(), "config")
+			writeTestFile(t, master, testManifestKubeconfig)
+			paths := []string{dir, master}
+
+			first, err := buildManifest("~/.kube", paths, nil, &manifest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if valid := first.validSources(); len(valid) != 2 || valid[0] != filepath.Join(dir, "prod.yaml") {
+				t.Errorf("got valid sources %v, want prod.yaml and the master config", valid)
+			}
+
+			test.change(t, dir, master)
+			ksPath := "~/.kube"
+			if test.ksPath != "" {
+				ksPath = test.ksPath
+			}
+
+			second, err := buildManifest(ksPath, paths, nil, first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := second.equal(first); got != test.wantEqual {
+				t.Errorf("got equal %v, want %v", got, test.wantEqual)
+			}
+		})
+	}
+}
+
+func TestRecordOwnWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		outsideKS bool
+		wantEqual bool
+	}{
+		{
+			name:      "master config written by ks",
+			wantEqual: true,
+		},
+		{
+			name:      "master config edited outside ks afterwards",
+			outsideKS: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, filepath.Join(dir, "prod.yaml"), testManifestKubeconfig)
+
+			prevMasterConfigPath, prevManifestPath := masterConfigPath, manifestPath
+			masterConfigPath = filepath.Join(t.TempDir(), "config")
+			manifestPath = filepath.Join(t.TempDir(), "manifest.yaml")
+			t.Cleanup(func() { masterConfigPath, manifestPath = prevMasterConfigPath, prevManifestPath })
+			writeTestFile(t, masterConfigPath, testManifestKubeconfig)
+			paths := []string{dir, masterConfigPath}
+
+			m, err := buildManifest("~/.kube", paths, nil, &manifest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = m.save(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Switch contexts the way ks does
+			conf, err := clientcmd.LoadFromFile(masterConfigPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf.CurrentContext = "prod"
+			if err = writeKubeconfig(masterConfigPath, conf); err != nil {
+				t.Fatal(err)
+			}
+			if test.outsideKS {
+				writeTestFile(t, masterConfigPath, testManifestKubeconfig)
+			}
+
+			recorded, err := loadManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			current, err := buildManifest("~/.kube", paths, nil, recorded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := current.equal(recorded); got != test.wantEqual {
+				t.Errorf("got equal %v, want %v", got, test.wantEqual)
+			}
+		})
+	}
+}
+
+func TestFingerprintsEqual(t *testing.T) {
+	now := time.Now()
+	fp := fingerprint{Path: "/a", Size: 1, ModTime: now, Hash: "x"}
+
+	tests := []struct {
+		name string
+		a, b []fingerprint
+		want bool
+	}{
+		{name: "both empty", want: true},
+		{name: "same", a: []fingerprint{fp}, b: []fingerprint{fp}, want: true},
+		{name: "different length", a: []fingerprint{fp}, b: []fingerprint{fp, fp}},
+		{name: "different path", a: []fingerprint{fp}, b: []fingerprint{{Path: "/b", Size: 1, ModTime: now, Hash: "x"}}},
+		{name: "different size", a: []fingerprint{fp}, b: []fingerprint{{Path: "/a", Size: 2, ModTime: now, Hash: "x"}}},
+		{
+			name: "different modification time",
+			a:    []fingerprint{fp},
+			b:    []fingerprint{{Path: "/a", Size: 1, ModTime: now.Add(time.Second), Hash: "x"}},
+		},
+		{name: "different hash", a: []fingerprint{fp}, b: []fingerprint{{Path: "/a", Size: 1, ModTime: now, Hash: "y"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fingerprintsEqual(test.a, test.b); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// invalidSuffixChars matches characters that should not appear in suffixes derived from file names.
+var invalidSuffixChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// loadKubeconfigFiles merges the given kubeconfig files. Files closer to the beginning of the list take precedence.
+// Contexts are renamed according to the naming rules from the given settings before merging.
+//
+// Unlike clientcmd.ClientConfigLoadingRules, clusters and users that are defined differently in several files under
+// the same name are not silently dropped. Instead, they are renamed with a suffix derived from the file they came from,
+// and contexts in that file are updated to refer to them by their new names.
+func loadKubeconfigFiles(files []string, s *settings) (*api.Config, error) {
+	errs := make([]error, 0)
+	merged := api.NewConfig()
+
+	for _, file := range files {
+		conf, err := clientcmd.LoadFromFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error loading config file \"%s\": %v", file, err))
+			continue
+		}
+
+		if _, err = applyNamingRules(conf, file, s); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Resolve relative paths now so definitions from different files can be compared
+		if err = clientcmd.ResolveLocalPaths(conf); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// The master config only holds the results of earlier merges, so its outdated definitions must be shadowed
+		// rather than kept under a new name
+		if !isMasterConfig(file) {
+			resolveCollisions(merged, conf, sourceSuffix(file))
+		}
+		mergeKubeconfig(merged, conf)
+	}
+
+	return merged, utilerrors.NewAggregate(errs)
+}
+
+// mergeKubeconfig merges src into dst. Entries that already exist in dst take precedence.
+func mergeKubeconfig(dst, src *api.Config) {
+	if dst.CurrentContext == "" {
+		dst.CurrentContext = src.CurrentContext
+	}
+
+	for name, cluster := range src.Clusters {
+		if _, exists := dst.Clusters[name]; !exists {
+			dst.Clusters[name] = cluster
+		}
+	}
+	for name, authInfo := range src.AuthInfos {
+		if _, exists := dst.AuthInfos[name]; !exists {
+			dst.AuthInfos[name] = authInfo
+		}
+	}
+	for name, ctx := range src.Contexts {
+		if _, exists := dst.Contexts[name]; !exists {
+			dst.Contexts[name] = ctx
+		}
+	}
+	for name, ext := range src.Extensions {
+		if _, exists := dst.Extensions[name]; !exists {
+			dst.Extensions[name] = ext
+		}
+	}
+}
+
+// resolveCollisions renames clusters and users in src that have the same name as, but a different definition from,
+// clusters and users already in dst. New names are formed by appending the given suffix. Contexts in src are updated
+// to refer to the new names. The returned maps contain the new names of renamed clusters and users, keyed by their
+// original names.
+func resolveCollisions(dst, src *api.Config, suffix string) (clusterRenames, userRenames map[string]string) {
+	clusterRenames = make(map[string]string)
+	userRenames = make(map[string]string)
+
+	for _, name := range sortedKeys(src.Clusters) {
+		cluster := src.Clusters[name]
+		existing, exists := dst.Clusters[name]
+		if !exists || clustersEqual(existing, cluster) {
+			continue
+		}
+
+		newName := collisionName(name, suffix, func(candidate string) (bool, bool) {
+			other, taken := dst.Clusters[candidate]
+			if !taken {
+				_, taken = src.Clusters[candidate]
+				return taken, false
+			}
+			return taken, clustersEqual(other, cluster)
+		})
+
+		delete(src.Clusters, name)
+		src.Clusters[newName] = cluster
+		clusterRenames[name] = newName
+		for _, ctx := range src.Contexts {
+			if ctx.Cluster == name {
+				ctx.Cluster = newName
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(src.AuthInfos) {
+		authInfo := src.AuthInfos[name]
+		existing, exists := dst.AuthInfos[name]
+		if !exists || authInfosEqual(existing, authInfo) {
+			continue
+		}
+
+		newName := collisionName(name, suffix, func(candidate string) (bool, bool) {
+			other, taken := dst.AuthInfos[candidate]
+			if !taken {
+				_, taken = src.AuthInfos[candidate]
+				return taken, false
+			}
+			return taken, authInfosEqual(other, authInfo)
+		})
+
+		delete(src.AuthInfos, name)
+		src.AuthInfos[newName] = authInfo
+		userRenames[name] = newName
+		for _, ctx := range src.Contexts {
+			if ctx.AuthInfo == name {
+				ctx.AuthInfo = newName
+			}
+		}
+	}
+
+	return clusterRenames, userRenames
+}
+
+// collisionName returns a new name for an entry that collides with another entry of the same name. The lookup function
+// reports whether a candidate name is already taken and, if so, whether the entry that has it is identical to the one
+// being renamed, in which case the candidate can be reused.
+func collisionName(name, suffix string, lookup func(candidate string) (taken bool, identical bool)) string {
+	base := name + "-" + suffix
+	candidate := base
+	for i := 2; ; i++ {
+		taken, identical := lookup(candidate)
+		if !taken || identical {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// sourceSuffix derives a short suffix from the given kubeconfig file path. The file name without its extension is used,
+// unless it is the generic name "config", in which case the name of the parent directory is used.
+func sourceSuffix(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if name == "config" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+
+	name = strings.Trim(invalidSuffixChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if name == "" {
+		return "source"
+	}
+	return name
+}
+
+// clustersEqual returns true if both clusters have the same definition, regardless of where they came from.
+func clustersEqual(a, b *api.Cluster) bool {
+	aCopy, bCopy := *a, *b
+	aCopy.LocationOfOrigin, bCopy.LocationOfOrigin = "", ""
+	return reflect.DeepEqual(aCopy, bCopy)
+}
+
+// authInfosEqual returns true if both users have the same definition, regardless of where they came from.
+func authInfosEqual(a, b *api.AuthInfo) bool {
+	aCopy, bCopy := *a, *b
+	aCopy.LocationOfOrigin, bCopy.LocationOfOrigin = "", ""
+	return reflect.DeepEqual(aCopy, bCopy)
+}
+
+// contextOrigins returns the paths of the files the contexts in the given config came from, keyed by context name.
+func contextOrigins(conf *api.Config) map[string]string {
+	origins := make(map[string]string, len(conf.Contexts))
+	for name, ctx := range conf.Contexts {
+		origins[name] = ctx.LocationOfOrigin
+	}
+	return origins
+}
+
+// loadOrigins loads the paths of the files contexts came from during the last merge, keyed by context name.
+func loadOrigins() map[string]string {
+	origins := make(map[string]string)
+	if err := readYAMLFile(originsPath, &origins); err != nil {
+		warnf("Error loading context origins: %v", err)
+	}
+	return origins
+}
+
+// contextsEqual returns true if both contexts have the same definition, regardless of where they came from.
+func contextsEqual(a, b *api.Context) bool {
+	aCopy, bCopy := *a, *b
+	aCopy.LocationOfOrigin, bCopy.LocationOfOrigin = "", ""
+	return reflect.DeepEqual(aCopy, bCopy)
+}
+
+// sortedKeys returns the keys of the given map in alphabetical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
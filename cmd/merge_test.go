@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestCollisionName(t *testing.T) {
+	tests := []struct {
+		name string
+		// taken maps taken candidate names to whether their entries are identical to the one being renamed
+		taken map[string]bool
+		want  string
+	}{
+		{
+			name: "free candidate",
+			want: "prod-eks",
+		},
+		{
+			name:  "identical entry reuses candidate",
+			taken: map[string]bool{"prod-eks": true},
+			want:  "prod-eks",
+		},
+		{
+			name:  "different entry gets number",
+			taken: map[string]bool{"prod-eks": false},
+			want:  "prod-eks-2",
+		},
+		{
+			name:  "numbered identical entry is reused",
+			taken: map[string]bool{"prod-eks": false, "prod-eks-2": false, "prod-eks-3": true},
+			want:  "prod-eks-3",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := collisionName("prod", "eks", func(candidate string) (bool, bool) {
+				identical, taken := test.taken[candidate]
+				return taken, identical
+			})
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveCollisions(t *testing.T) {
+	cluster := func(server string) *api.Cluster {
+		return &api.Cluster{Server: server, LocationOfOrigin: server}
+	}
+	user := func(token string) *api.AuthInfo {
+		return &api.AuthInfo{Token: token}
+	}
+
+	tests := []struct {
+		name           string
+		dstClusters    map[string]*api.Cluster
+		srcClusters    map[string]*api.Cluster
+		dstUsers       map[string]*api.AuthInfo
+		srcUsers       map[string]*api.AuthInfo
+		wantClusters   map[string]string
+		wantUsers      map[string]string
+		wantCtxCluster string
+		wantCtxUser    string
+	}{
+		{
+			name:           "no collision",
+			dstClusters:    map[string]*api.Cluster{"other": cluster("https://a")},
+			srcClusters:    map[string]*api.Cluster{"prod": cluster("https://b")},
+			wantClusters:   map[string]string{},
+			wantUsers:      map[string]string{},
+			wantCtxCluster: "prod",
+			wantCtxUser:    "admin",
+		},
+		{
+			name:           "identical definitions are shared",
+			dstClusters:    map[string]*api.Cluster{"prod": cluster("https://a")},
+			srcClusters:    map[string]*api.Cluster{"prod": {Server: "https://a", LocationOfOrigin: "elsewhere"}},
+			wantClusters:   map[string]string{},
+			wantUsers:      map[string]string{},
+			wantCtxCluster: "prod",
+			wantCtxUser:    "admin",
+		},
+		{
+			name:           "different definition is renamed",
+			dstClusters:    map[string]*api.Cluster{"prod": cluster("https://a")},
+			srcClusters:    map[string]*api.Cluster{"prod": cluster("https://b")},
+			wantClusters:   map[string]string{"prod": "prod-eks"},
+			wantUsers:      map[string]string{},
+			wantCtxCluster: "prod-eks",
+			wantCtxUser:    "admin",
+		},
+		{
+			name: "identical candidate is reused",
+			dstClusters: map[string]*api.Cluster{
+				"prod":     cluster("https://a"),
+				"prod-eks": cluster("https://b"),
+			},
+			srcClusters:    map[string]*api.Cluster{"prod": cluster("https://b")},
+			wantClusters:   map[string]string{"prod": "prod-eks"},
+			wantUsers:      map[string]string{},
+			wantCtxCluster: "prod-eks",
+			wantCtxUser:    "admin",
+		},
+		{
+			name: "different candidate gets number",
+			dstClusters: map[string]*api.Cluster{
+				"prod":     cluster("https://a"),
+				"prod-eks": cluster("https://c"),
+			},
+			srcClusters:    map[string]*api.Cluster{"prod": cluster("https://b")},
+			wantClusters:   map[string]string{"prod": "prod-eks-2"},
+			wantUsers:      map[string]string{},
+			wantCtxCluster: "prod-eks-2",
+			wantCtxUser:    "admin",
+		},
+		{
+			name:        "candidate taken in source gets number",
+			dstClusters: map[string]*api.Cluster{"prod": cluster("https://a")},
+			srcClusters: map[string]*api.Cluster{
+				"prod":     cluster("https://b"),
+				"prod-eks": cluster("https://c"),
+			},
+			wantClusters:   map[string]string{"prod": "prod-eks-2"},
+			wantUsers:      map[string]string{},
+			wantCtxCluster: "prod-eks-2",
+			wantCtxUser:    "admin",
+		},
+		{
+			name:           "different user is renamed",
+			dstUsers:       map[string]*api.AuthInfo{"admin": user("abc")},
+			srcUsers:       map[string]*api.AuthInfo{"admin": user("def")},
+			wantClusters:   map[string]string{},
+			wantUsers:      map[string]string{"admin": "admin-eks"},
+			wantCtxCluster: "prod",
+			wantCtxUser:    "admin-eks",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := api.NewConfig()
+			for name, c := range test.dstClusters {
+				dst.Clusters[name] = c
+			}
+			for name, u := range test.dstUsers {
+				dst.AuthInfos[name] = u
+			}
+
+			src := api.NewConfig()
+			for name, c := range test.srcClusters {
+				src.Clusters[name] = c
+			}
+			for name, u := range test.srcUsers {
+				src.AuthInfos[name] = u
+			}
+			src.Contexts["prod"] = &api.Context{Cluster: "prod", AuthInfo: "admin"}
+
+			clusterRenames, userRenames := resolveCollisions(dst, src, "eks")
+			if !reflect.DeepEqual(clusterRenames, test.wantClusters) {
+				t.Errorf("got cluster renames %v, want %v", clusterRenames, test.wantClusters)
+			}
+			if !reflect.DeepEqual(userRenames, test.wantUsers) {
+				t.Errorf("got user renames %v, want %v", userRenames, test.wantUsers)
+			}
+
+			ctx := src.Contexts["prod"]
+			if ctx.Cluster != test.wantCtxCluster || ctx.AuthInfo != test.wantCtxUser {
+				t.Errorf("context refers to cluster %q and user %q, want %q and %q", ctx.Cluster, ctx.AuthInfo,
+					test.wantCtxCluster, test.wantCtxUser)
+			}
+			for oldName, newName := range clusterRenames {
+				if _, ok := src.Clusters[newName]; !ok {
+					t.Errorf("cluster %s was not moved to %s", oldName, newName)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// contextNameData is passed to context name templates.
+type contextNameData struct {
+	// Name is the name of the context after rewrite rules have been applied.
+	Name string
+	// Original is the name of the context as it appears in the file.
+	Original string
+	// File is the name of the file the context came from, without its extension.
+	File string
+	// Dir is the name of the directory containing the file the context came from.
+	Dir string
+}
+
+// contextName returns the name the given context from the file at the given path should have after the merge.
+func (source *sourceSettings) contextName(name, path string) (string, error) {
+	newName := name
+	for i, re := range source.rewrite {
+		newName = re.ReplaceAllString(newName, source.Rewrite[i].Replace)
+	}
+
+	if source.template != nil {
+		var sb strings.Builder
+		if err := source.template.Execute(&sb, contextNameData{
+			Name:     newName,
+			Original: name,
+			File:     strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+			Dir:      filepath.Base(filepath.Dir(path)),
+		}); err != nil {
+			return "", fmt.Errorf("error applying template for %s: %v", source.Path, err)
+		}
+		newName = sb.String()
+	}
+
+	return source.Prefix + newName, nil
+}
+
+// applyNamingRules renames contexts in the given config loaded from the file at the given path according to the
+// settings for that file. It returns the original names of renamed contexts, keyed by their new names. Contexts keep
+// their original names if the rules give them an empty name or one that collides with another context from the same
+// file.
+func applyNamingRules(conf *api.Config, path string, s *settings) (map[string]string, error) {
+	originals := make(map[string]string)
+
+	// The master config only contains names that have already been through the rules
+	source := s.sourceFor(path)
+	if source == nil || isMasterConfig(path) {
+		return originals, nil
+	}
+
+	names := sortedKeys(conf.Contexts)
+	newNames := make(map[string]string, len(names))
+	for _, name := range names {
+		newName, err := source.contextName(name, path)
+		if err != nil {
+			return nil, err
+		}
+		if newName == "" {
+			newName = name
+		}
+		newNames[name] = newName
+	}
+
+	// When several contexts want the same name, a context that already has it wins, followed by the first one in
+	// alphabetical order. The others keep their original names, which can collide again, so repeat until nothing does.
+	for collided := true; collided; {
+		collided = false
+		claims := make(map[string][]string)
+		for _, name := range names {
+			claims[newNames[name]] = append(claims[newNames[name]], name)
+		}
+
+		for newName, claimants := range claims {
+			if len(claimants) < 2 {
+				continue
+			}
+			winner := claimants[0]
+			if contains(claimants, newName) {
+				winner = newName
+			}
+			for _, name := range claimants {
+				if name != winner {
+					newNames[name] = name
+					collided = true
+				}
+			}
+		}
+	}
+
+	renamed := make(map[string]*api.Context, len(names))
+	for _, name := range names {
+		newName := newNames[name]
+		renamed[newName] = conf.Contexts[name]
+		if newName != name {
+			originals[newName] = name
+		}
+	}
+
+	if newName, ok := newNames[conf.CurrentContext]; ok {
+		conf.CurrentContext = newName
+	}
+	conf.Contexts = renamed
+	return originals, nil
+}
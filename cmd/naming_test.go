@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestApplyNamingRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		rules         string
+		contexts      []string
+		current       string
+		wantContexts  map[string]string
+		wantOriginals map[string]string
+		wantCurrent   string
+	}{
+		{
+			name:          "prefix",
+			rules:         `prefix: work-`,
+			contexts:      []string{"prod", "staging"},
+			current:       "prod",
+			wantContexts:  map[string]string{"work-prod": "prod", "work-staging": "staging"},
+			wantOriginals: map[string]string{"work-prod": "prod", "work-staging": "staging"},
+			wantCurrent:   "work-prod",
+		},
+		{
+			name:          "empty name keeps original",
+			rules:         "rewrite:\n  - match: ^.*$\n    replace: \"\"",
+			contexts:      []string{"prod"},
+			wantContexts:  map[string]string{"prod": "prod"},
+			wantOriginals: map[string]string{},
+		},
+		{
+			name:          "rename onto a context that keeps its name",
+			rules:         "rewrite:\n  - match: ^aws-\n    replace: \"\"",
+			contexts:      []string{"aws-prod", "prod"},
+			current:       "aws-prod",
+			wantContexts:  map[string]string{"aws-prod": "aws-prod", "prod": "prod"},
+			wantOriginals: map[string]string{},
+			wantCurrent:   "aws-prod",
+		},
+		{
+			name:          "two contexts renamed to the same name",
+			rules:         "rewrite:\n  - match: ^(aws|gcp)-\n    replace: \"\"",
+			contexts:      []string{"aws-prod", "gcp-prod"},
+			wantContexts:  map[string]string{"prod": "aws-prod", "gcp-prod": "gcp-prod"},
+			wantOriginals: map[string]string{"prod": "aws-prod"},
+		},
+		{
+			name:          "falling back to the original name collides again",
+			rules:         "rewrite:\n  - match: ^p$\n    replace: q\n  - match: ^r$\n    replace: p",
+			contexts:      []string{"p", "q", "r"},
+			wantContexts:  map[string]string{"p": "p", "q": "q", "r": "r"},
+			wantOriginals: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "kube", "config")
+
+			prevSettingsPath := settingsPath
+			settingsPath = filepath.Join(dir, "settings.yaml")
+			t.Cleanup(func() { settingsPath = prevSettingsPath })
+			rules := strings.ReplaceAll(test.rules, "\n", "\n  ")
+			settingsYAML := "sources:\n- path: " + filepath.Dir(path) + "\n  " + rules + "\n"
+			if err := os.WriteFile(settingsPath, []byte(settingsYAML), 0600); err != nil {
+				t.Fatal(err)
+			}
+			s, err := loadSettings()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conf := api.NewConfig()
+			for _, name := range test.contexts {
+				// Clusters are named after the original context names so contexts can be told apart afterwards
+				conf.Contexts[name] = &api.Context{Cluster: name}
+			}
+			conf.CurrentContext = test.current
+
+			originals, err := applyNamingRules(conf, path, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contexts := make(map[string]string, len(conf.Contexts))
+			for name, ctx := range conf.Contexts {
+				contexts[name] = ctx.Cluster
+			}
+			if !reflect.DeepEqual(contexts, test.wantContexts) {
+				t.Errorf("got contexts %v, want %v", contexts, test.wantContexts)
+			}
+			if !reflect.DeepEqual(originals, test.wantOriginals) {
+				t.Errorf("got originals %v, want %v", originals, test.wantOriginals)
+			}
+			if conf.CurrentContext != test.wantCurrent {
+				t.Errorf("got current context %q, want %q", conf.CurrentContext, test.wantCurrent)
+			}
+		})
+	}
+}
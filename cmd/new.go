@@ -9,11 +9,14 @@ import (
 
 // newCmd represents the new command
 var newCmd = &cobra.Command{
-	Use:     "new <context>",
-	Aliases: []string{"n", "create"},
-	Args:    cobra.ExactArgs(1),
-	Short:   "Create a new context",
+	Use:               "new <context>",
+	Aliases:           []string{"n", "create"},
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Create a new context",
 	Long: `This command creates a new context in the kubeconfig pointed to by the KUBECONFIG env var.
+
+The context given with --from can be a unique prefix, substring or fuzzy match of its name unless --exact is used.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get args and flags
@@ -24,7 +27,7 @@ var newCmd = &cobra.Command{
 		flagNamespace := getStringFlag(cmd, "namespace")
 
 		// Load kubeconfig from file
-		confPath := os.Getenv("KUBECONFIG")
+		confPath := kubeconfigTarget(os.Getenv("KUBECONFIG"))
 		if confPath == "" {
 			fatalf("KUBECONFIG is not set. Please make sure KUBECONFIG is set correctly.")
 		}
@@ -41,12 +44,9 @@ var newCmd = &cobra.Command{
 		newCtx := &api.Context{}
 		if flagFrom != "" {
 			// Copy from existing context
-			existingCtx, exists := conf.Contexts[flagFrom]
-			if !exists {
-				fatalf(`No context exists with name %s`, flagFrom)
-			}
-
-			newCtx = existingCtx.DeepCopy()
+			flagFrom, err = resolveContext(conf, flagFrom, getBoolFlag(cmd, "exact"))
+			handleFatalf(err, "Error finding context: %v", err)
+			newCtx = conf.Contexts[flagFrom].DeepCopy()
 		}
 
 		// Set fields on new context
@@ -73,6 +73,11 @@ var newCmd = &cobra.Command{
 		err = writeKubeconfig(confPath, conf)
 		handleFatalf(err, "Error writing config to %s: %v", confPath, err)
 
+		// Make sure the new context survives the next merge
+		updateOverlay(confPath, func(o *overlay) {
+			o.recordNew(argName, newCtx)
+		})
+
 		infof("Created context %s.", argName)
 	},
 }
@@ -80,7 +85,12 @@ var newCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(newCmd)
 	newCmd.Flags().StringP("from", "f", "", "Copy from existing context by name")
+	newCmd.Flags().Bool("exact", false, "Only accept the exact name for --from")
 	newCmd.Flags().StringP("cluster", "c", "", "The cluster for the new context")
 	newCmd.Flags().StringP("user", "u", "", "The user for the new context")
 	newCmd.Flags().StringP("namespace", "n", "", "The namespace for the new context")
+	_ = newCmd.RegisterFlagCompletionFunc("from", completeContexts)
+	_ = newCmd.RegisterFlagCompletionFunc("cluster", completeClusters)
+	_ = newCmd.RegisterFlagCompletionFunc("user", completeUsers)
+	_ = newCmd.RegisterFlagCompletionFunc("namespace", completeNamespaces(false))
 }
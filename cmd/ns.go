@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// nsCmd represents the ns command
+var nsCmd = &cobra.Command{
+	Use:               "ns",
+	Aliases:           []string{"namespaces"},
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "List namespaces in the current context's cluster",
+	Long: `This command lists the namespaces in the cluster of the current context, marking the one the context uses. If
+the user is not allowed to list namespaces, the namespaces used before with this cluster are listed instead.
+
+Use "ks switch -n <namespace>" to change the namespace.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		ctx, ok := conf.Contexts[conf.CurrentContext]
+		if !ok {
+			fatalf("No current context. Use ks switch to choose one.")
+		}
+		current := ctx.Namespace
+		if current == "" {
+			current = "default"
+		}
+
+		namespaces, err := fetchNamespaces(conf, conf.CurrentContext, clusterTimeout)
+		if apierrors.IsForbidden(err) {
+			warnf("Not allowed to list namespaces in context %s. Showing namespaces used before.", conf.CurrentContext)
+			namespaces = usedNamespaces(conf, conf.CurrentContext)
+		} else {
+			handleFatalf(err, "Error listing namespaces in context %s: %v", conf.CurrentContext, err)
+		}
+
+		for _, name := range namespaces {
+			if name == current {
+				infof("%s (current)", name)
+			} else {
+				infof("%s", name)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nsCmd)
+}
+
+// fetchNamespaces returns the names of all namespaces in the cluster of the context with the given name, in
+// alphabetical order. It gives up if the cluster doesn't answer within the given timeout.
+func fetchNamespaces(conf *api.Config, ctxName string, timeout time.Duration) ([]string, error) {
+	client, err := clusterClientFor(conf, ctxName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	namespaces, err := client.listNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// usedNamespaces returns the namespaces known to have been used with the cluster of the context with the given name,
+// either by contexts for the same cluster or in the history, in alphabetical order.
+func usedNamespaces(conf *api.Config, ctxName string) []string {
+	cluster := conf.Contexts[ctxName].Cluster
+	used := map[string]bool{"default": true}
+
+	for _, ctx := range conf.Contexts {
+		if ctx.Cluster == cluster && ctx.Namespace != "" {
+			used[ctx.Namespace] = true
+		}
+	}
+
+	// A broken history just means fewer suggestions
+	if h, err := loadHistory(); err == nil {
+		for _, entry := range h.Entries {
+			if ctx, ok := conf.Contexts[entry.Context]; ok && ctx.Cluster == cluster && entry.Namespace != "" {
+				used[entry.Namespace] = true
+			}
+		}
+	}
+
+	return sortedKeys(used)
+}
+
+// verifyNamespace makes sure a namespace with the given name exists in the cluster of the context with the given
+// name. If it doesn't, it is created if create is true, and ks exits with an error otherwise. If the cluster can't tell,
+// e.g. because it is unreachable, only a warning is printed.
+func verifyNamespace(conf *api.Config, ctxName string, name string, create bool) {
+	client, err := clusterClientFor(conf, ctxName)
+	if err != nil {
+		warnf("Could not check whether namespace %s exists: %v", name, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+	defer cancel()
+
+	exists, err := client.namespaceExists(ctx, name)
+	if err != nil {
+		warnf("Could not check whether namespace %s exists: %v", name, err)
+		return
+	} else if exists {
+		return
+	}
+
+	if !create {
+		fatalf(`Namespace %s does not exist in context %s. Use --create to create it.`, name, ctxName)
+	}
+
+	err = client.createNamespace(ctx, name)
+	handleFatalf(err, "Error creating namespace %s: %v", name, err)
+	infof("Created namespace %s.", name)
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+// outputFormats lists the values accepted by -o, for help texts and completion.
+var outputFormats = []string{"json", "yaml", "name", "wide", "go-template="}
+
+// outputHelp documents the fields of machine-readable output. It is appended to the long description of every command
+// that supports -o.
+const outputHelp = `
+Use -o to print machine-readable output: "json", "yaml", "name" (context names only), "wide" (a table) or
+"go-template=<template>", which is executed once per context. Contexts have these fields, which won't change:
+
+  context    name of the context
+  cluster    name of the context's cluster
+  server     URL of the cluster's API server
+  user       name of the context's user
+  namespace  namespace of the context
+  authType   how the user authenticates: exec, auth-provider, client-certificate, token, basic or none
+  source     kubeconfig file the context came from
+  current    whether the context is the current context
+  health     with "ks list --health" only: status, latency, version and error from "ks ping"
+`
+
+// contextInfo describes a context in machine-readable output. The JSON names of its fields are part of the interface
+// of ks and are documented in outputHelp, so they must not change.
+type contextInfo struct {
+	Context   string `json:"context"`
+	Cluster   string `json:"cluster"`
+	Server    string `json:"server"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace"`
+	AuthType  string `json:"authType"`
+	Source    string `json:"source"`
+	Current   bool   `json:"current"`
+	// Health is only set by ks list --health.
+	Health *pingResult `json:"health,omitempty"`
+}
+
+// outputPrinter prints contexts in one of the formats accepted by -o.
+type outputPrinter struct {
+	format   string
+	template *template.Template
+}
+
+// addOutputFlag adds the -o flag to the given command and documents its fields. Messages are written to stderr when -o
+// is given, including those from the merge that runs first, so they don't end up in the output.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Long += outputHelp
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if getStringFlag(cmd, "output") != "" {
+			messageOut = os.Stderr
+		}
+		rootCmd.PersistentPreRun(cmd, args)
+	}
+	cmd.Flags().StringP("output", "o", "", "Output format: "+strings.Join(outputFormats, "|"))
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		outputFormats,
+		cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace,
+	))
+}
+
+// getOutputPrinter returns a printer for the format given with -o on the given command, or nil if none was given.
+func getOutputPrinter(cmd *cobra.Command) (*outputPrinter, error) {
+	format := getStringFlag(cmd, "output")
+	switch format {
+	case "":
+		return nil, nil
+	case "json", "yaml", "name", "wide":
+		return &outputPrinter{format: format}, nil
+	}
+
+	text, ok := strings.CutPrefix(format, "go-template=")
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %s (supported formats: %s)", format,
+			strings.Join(outputFormats, ", "))
+	}
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return &outputPrinter{format: "go-template", template: tmpl}, nil
+}
+
+// newContextInfo describes the context with the given name from the given config. Its source is looked up in the given
+// origins, falling back to the file the context was loaded from.
+func newContextInfo(conf *api.Config, name string, origins map[string]string) contextInfo {
+	ctx := conf.Contexts[name]
+	info := contextInfo{
+		Context:   name,
+		Cluster:   ctx.Cluster,
+		User:      ctx.AuthInfo,
+		Namespace: ctx.Namespace,
+		AuthType:  authType(conf.AuthInfos[ctx.AuthInfo]),
+		Source:    origins[name],
+		Current:   name == conf.CurrentContext,
+	}
+	if cluster, ok := conf.Clusters[ctx.Cluster]; ok {
+		info.Server = cluster.Server
+	}
+	if info.Source == "" {
+		info.Source = ctx.LocationOfOrigin
+	}
+	return info
+}
+
+// authType returns how the given user authenticates. If several methods are configured, the first one client-go tries
+// wins.
+func authType(authInfo *api.AuthInfo) string {
+	switch {
+	case authInfo == nil:
+		return "none"
+	case authInfo.Exec != nil:
+		return "exec"
+	case authInfo.AuthProvider != nil:
+		return "auth-provider"
+	case authInfo.ClientCertificate != "" || len(authInfo.ClientCertificateData) > 0:
+		return "client-certificate"
+	case authInfo.Token != "" || authInfo.TokenFile != "":
+		return "token"
+	case authInfo.Username != "" || authInfo.Password != "":
+		return "basic"
+	default:
+		return "none"
+	}
+}
+
+// print prints the given contexts to stdout. If single is true, JSON and YAML output holds the only context rather
+// than a list.
+func (p *outputPrinter) print(infos []contextInfo, single bool) error {
+	switch p.format {
+	case "json", "yaml":
+		var v any = infos
+		if single {
+			v = infos[0]
+		}
+
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if p.format == "yaml" {
+			if out, err = yaml.JSONToYAML(out); err != nil {
+				return err
+			}
+		} else {
+			out = append(out, '\n')
+		}
+		_, err = os.Stdout.Write(out)
+		return err
+
+	case "name":
+		for _, info := range infos {
+			fmt.Println(info.Context)
+		}
+		return nil
+
+	case "wide":
+		withHealth := len(infos) > 0 && infos[0].Health != nil
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		header := "CURRENT\tCONTEXT\tCLUSTER\tSERVER\tUSER\tNAMESPACE\tAUTH TYPE\tSOURCE"
+		if withHealth {
+			header += "\tHEALTH"
+		}
+		fmt.Fprintln(w, header)
+		for _, info := range infos {
+			current := ""
+			if info.Current {
+				current = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", current, info.Context, info.Cluster, info.Server,
+				info.User, info.Namespace, info.AuthType, info.Source)
+			if withHealth {
+				fmt.Fprintf(w, "\t%s", info.Health)
+			}
+			fmt.Fprintln(w)
+		}
+		return w.Flush()
+
+	default:
+		// Templates see the same field names as JSON output
+		for _, info := range infos {
+			data, err := json.Marshal(info)
+			if err != nil {
+				return err
+			}
+			fields := make(map[string]any)
+			if err = json.Unmarshal(data, &fields); err != nil {
+				return err
+			}
+			if err = p.template.Execute(os.Stdout, fields); err != nil {
+				return fmt.Errorf("error executing template: %v", err)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+// overlay records changes made to contexts by ks commands so that they can be re-applied every time kubeconfig files
+// from KSPATH are merged.
+type overlay struct {
+	// Deleted lists the names of contexts that should be removed after merging.
+	Deleted []string `json:"deleted,omitempty"`
+	// Renamed maps original context names to the names they should be given after merging.
+	Renamed map[string]string `json:"renamed,omitempty"`
+	// Contexts holds contexts created by the user, keyed by name.
+	Contexts map[string]*overlayContext `json:"contexts,omitempty"`
+}
+
+// overlayContext is a user-created context stored in the overlay.
+type overlayContext struct {
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// overlayCmd represents the overlay command
+var overlayCmd = &cobra.Command{
+	Use:   "overlay",
+	Short: "Inspect or clear changes made to contexts by ks",
+	Long: `Changes made by "ks delete", "ks rename" and "ks new" are recorded in ${HOME}/.ks/overlay.yaml and re-applied
+every time kubeconfig files from KSPATH are merged, so they persist even though the original files are never changed.
+`,
+}
+
+// overlayShowCmd represents the overlay show command
+var overlayShowCmd = &cobra.Command{
+	Use:               "show",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Show the current overlay",
+	Run: func(cmd *cobra.Command, args []string) {
+		o, err := loadOverlay()
+		handleFatalf(err, "Error loading overlay: %v", err)
+
+		if o.isEmpty() {
+			infof("Overlay is empty.")
+			return
+		}
+
+		output, err := yaml.Marshal(o)
+		handleFatalf(err, "Error encoding overlay: %v", err)
+		infof("%s", output)
+	},
+}
+
+// overlayResetCmd represents the overlay reset command
+var overlayResetCmd = &cobra.Command{
+	Use:               "reset",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Clear the overlay",
+	Long: `This command removes all recorded deletions, renames and user-created contexts, and merges kubeconfig files from
+KSPATH again. Deleted contexts reappear, renamed contexts go back to their original names and user-created contexts are
+removed.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		o, err := loadOverlay()
+		handleFatalf(err, "Error loading overlay: %v", err)
+
+		// The master config is an input to the merge, so contexts the overlay added to it must be removed first or
+		// they would survive the merge
+		if _, err = os.Stat(masterConfigPath); err == nil {
+			conf, err := loadKubeconfig([]string{masterConfigPath})
+			handleFatalf(err, "Error loading config from %s: %v", masterConfigPath, err)
+
+			o.revert(conf)
+			err = writeKubeconfig(masterConfigPath, conf)
+			handleFatalf(err, "Error writing config: %v", err)
+		} else if !os.IsNotExist(err) {
+			fatalf("Error checking file %s: %v", masterConfigPath, err)
+		}
+
+		err = os.RemoveAll(overlayPath)
+		handleFatalf(err, "Error removing %s: %v", overlayPath, err)
+
+		syncMasterConfig(true)
+		infof("Overlay cleared.")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(overlayCmd)
+	overlayCmd.AddCommand(overlayShowCmd)
+	overlayCmd.AddCommand(overlayResetCmd)
+}
+
+// loadOverlay loads the overlay from file. An empty overlay is returned if the file does not exist.
+func loadOverlay() (*overlay, error) {
+	o := &overlay{}
+	if err := readYAMLFile(overlayPath, o); err != nil {
+		return nil, err
+	}
+
+	if o.Renamed == nil {
+		o.Renamed = make(map[string]string)
+	}
+	if o.Contexts == nil {
+		o.Contexts = make(map[string]*overlayContext)
+	}
+
+	return o, nil
+}
+
+// save writes the overlay to file.
+func (o *overlay) save() error {
+	return writeYAMLFile(overlayPath, o)
+}
+
+// isEmpty returns true if the overlay records no changes.
+func (o *overlay) isEmpty() bool {
+	return len(o.Deleted) == 0 && len(o.Renamed) == 0 && len(o.Contexts) == 0
+}
+
+// apply applies the changes recorded in the overlay to the given config. Deletions are applied first, followed by
+// renames and finally user-created contexts.
+func (o *overlay) apply(conf *api.Config) {
+	for _, name := range o.Deleted {
+		delete(conf.Contexts, name)
+		if conf.CurrentContext == name {
+			conf.CurrentContext = ""
+		}
+	}
+
+	// Apply renames in a stable order
+	oldNames := make([]string, 0, len(o.Renamed))
+	for oldName := range o.Renamed {
+		oldNames = append(oldNames, oldName)
+	}
+	sort.Strings(oldNames)
+
+	for _, oldName := range oldNames {
+		if ctx, ok := conf.Contexts[oldName]; ok {
+			delete(conf.Contexts, oldName)
+			conf.Contexts[o.Renamed[oldName]] = ctx
+			if conf.CurrentContext == oldName {
+				conf.CurrentContext = o.Renamed[oldName]
+			}
+		}
+	}
+
+	for name, ctx := range o.Contexts {
+		conf.Contexts[name] = &api.Context{
+			LocationOfOrigin: overlayPath,
+			Cluster:          ctx.Cluster,
+			AuthInfo:         ctx.User,
+			Namespace:        ctx.Namespace,
+		}
+	}
+}
+
+// revert removes the contexts that the overlay adds to the given config, i.e. renamed and user-created contexts. If the
+// current context was renamed, it is set back to its original name so the next merge restores it.
+func (o *overlay) revert(conf *api.Config) {
+	for oldName, newName := range o.Renamed {
+		delete(conf.Contexts, newName)
+		if conf.CurrentContext == newName {
+			conf.CurrentContext = oldName
+		}
+	}
+
+	for name := range o.Contexts {
+		delete(conf.Contexts, name)
+	}
+}
+
+// recordDelete records the deletion of the context with the given name.
+func (o *overlay) recordDelete(name string) {
+	delete(o.Contexts, name)
+
+	// If the context was renamed, the original name must be removed too
+	for oldName, newName := range o.Renamed {
+		if newName == name {
+			delete(o.Renamed, oldName)
+			o.addDeleted(oldName)
+		}
+	}
+
+	o.addDeleted(name)
+}
+
+// recordRename records that the context with the given old name has been renamed.
+func (o *overlay) recordRename(oldName, newName string) {
+	// User-created contexts can just be moved
+	if ctx, ok := o.Contexts[oldName]; ok {
+		delete(o.Contexts, oldName)
+		o.Contexts[newName] = ctx
+		return
+	}
+
+	// Collapse chains of renames so each original name maps directly to its latest name
+	for src, dst := range o.Renamed {
+		if dst == oldName {
+			if src == newName {
+				delete(o.Renamed, src)
+			} else {
+				o.Renamed[src] = newName
+			}
+			return
+		}
+	}
+
+	o.Renamed[oldName] = newName
+}
+
+// recordNew records the creation of the given context.
+func (o *overlay) recordNew(name string, ctx *api.Context) {
+	o.Contexts[name] = &overlayContext{
+		Cluster:   ctx.Cluster,
+		User:      ctx.AuthInfo,
+		Namespace: ctx.Namespace,
+	}
+}
+
+// addDeleted adds the given name to the list of deleted contexts if it is not already there.
+func (o *overlay) addDeleted(name string) {
+	for _, deleted := range o.Deleted {
+		if deleted == name {
+			return
+		}
+	}
+	o.Deleted = append(o.Deleted, name)
+}
+
+// updateOverlay loads the overlay, applies the given change to it and writes it back to file, but only if the given
+// kubeconfig path is the one managed by ks.
+func updateOverlay(confPath string, change func(o *overlay)) {
+	if !isMasterConfig(confPath) {
+		return
+	}
+
+	o, err := loadOverlay()
+	handleFatalf(err, "Error loading overlay: %v", err)
+
+	change(o)
+
+	err = o.save()
+	handleFatalf(err, "Error writing overlay: %v", err)
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// newTestOverlay returns an empty overlay, like loadOverlay does when there is no overlay file.
+func newTestOverlay() *overlay {
+	return &overlay{Renamed: make(map[string]string), Contexts: make(map[string]*overlayContext)}
+}
+
+func TestOverlayRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		changes     func(o *overlay)
+		wantDeleted []string
+		wantRenamed map[string]string
+		wantCreated []string
+	}{
+		{
+			name:        "rename",
+			changes:     func(o *overlay) { o.recordRename("a", "b") },
+			wantRenamed: map[string]string{"a": "b"},
+		},
+		{
+			name: "chained renames collapse",
+			changes: func(o *overlay) {
+				o.recordRename("a", "b")
+				o.recordRename("b", "c")
+			},
+			wantRenamed: map[string]string{"a": "c"},
+		},
+		{
+			name: "renaming back to the original name",
+			changes: func(o *overlay) {
+				o.recordRename("a", "b")
+				o.recordRename("b", "c")
+				o.recordRename("c", "a")
+			},
+			wantRenamed: map[string]string{},
+		},
+		{
+			name: "deleting a renamed context deletes its original name",
+			changes: func(o *overlay) {
+				o.recordRename("a", "b")
+				o.recordDelete("b")
+			},
+			wantDeleted: []string{"a", "b"},
+			wantRenamed: map[string]string{},
+		},
+		{
+			name: "deleting twice is recorded once",
+			changes: func(o *overlay) {
+				o.recordDelete("a")
+				o.recordDelete("a")
+			},
+			wantDeleted: []string{"a"},
+			wantRenamed: map[string]string{},
+		},
+		{
+			name: "renaming a created context moves it",
+			changes: func(o *overlay) {
+				o.recordNew("n", &api.Context{Cluster: "c", AuthInfo: "u"})
+				o.recordRename("n", "m")
+			},
+			wantRenamed: map[string]string{},
+			wantCreated: []string{"m"},
+		},
+		{
+			name: "deleting a created context",
+			changes: func(o *overlay) {
+				o.recordNew("n", &api.Context{Cluster: "c", AuthInfo: "u"})
+				o.recordDelete("n")
+			},
+			wantDeleted: []string{"n"},
+			wantRenamed: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := newTestOverlay()
+			test.changes(o)
+
+			if !reflect.DeepEqual(o.Deleted, test.wantDeleted) {
+				t.Errorf("got deleted %v, want %v", o.Deleted, test.wantDeleted)
+			}
+			if !reflect.DeepEqual(o.Renamed, test.wantRenamed) {
+				t.Errorf("got renamed %v, want %v", o.Renamed, test.wantRenamed)
+			}
+			if created := sortedKeys(o.Contexts); !reflect.DeepEqual(created, append([]string{}, test.wantCreated...)) {
+				t.Errorf("got created %v, want %v", created, test.wantCreated)
+			}
+		})
+	}
+}
+
+func TestOverlayApply(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      string
+		wantContexts []string
+		wantCurrent  string
+	}{
+		{
+			name:         "current context keeps its name",
+			current:      "staging",
+			wantContexts: []string{"mine", "prod-renamed", "staging"},
+			wantCurrent:  "staging",
+		},
+		{
+			name:         "renamed current context",
+			current:      "prod",
+			wantContexts: []string{"mine", "prod-renamed", "staging"},
+			wantCurrent:  "prod-renamed",
+		},
+		{
+			name:         "deleted current context",
+			current:      "old",
+			wantContexts: []string{"mine", "prod-renamed", "staging"},
+			wantCurrent:  "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := api.NewConfig()
+			for _, name := range []string{"old", "prod", "staging"} {
+				conf.Contexts[name] = &api.Context{Cluster: name, AuthInfo: "admin"}
+			}
+			conf.CurrentContext = test.current
+
+			o := newTestOverlay()
+			o.recordDelete("old")
+			o.recordRename("prod", "prod-renamed")
+			o.recordNew("mine", &api.Context{Cluster: "staging", AuthInfo: "admin", Namespace: "apps"})
+			o.apply(conf)
+
+			if contexts := sortedKeys(conf.Contexts); !reflect.DeepEqual(contexts, test.wantContexts) {
+				t.Errorf("got contexts %v, want %v", contexts, test.wantContexts)
+			}
+			if conf.CurrentContext != test.wantCurrent {
+				t.Errorf("got current context %q, want %q", conf.CurrentContext, test.wantCurrent)
+			}
+			if conf.Contexts["prod-renamed"].Cluster != "prod" {
+				t.Errorf("renamed context lost its cluster")
+			}
+			if mine := conf.Contexts["mine"]; mine.Cluster != "staging" || mine.Namespace != "apps" {
+				t.Errorf("created context has cluster %q and namespace %q", mine.Cluster, mine.Namespace)
+			}
+
+			// Reverting leaves only the contexts from the original files, under their original names
+			o.revert(conf)
+			if contexts := sortedKeys(conf.Contexts); !reflect.DeepEqual(contexts, []string{"staging"}) {
+				t.Errorf("got contexts %v after revert, want [staging]", contexts)
+			}
+			if test.current == "prod" && conf.CurrentContext != "prod" {
+				t.Errorf("got current context %q after revert, want prod", conf.CurrentContext)
+			}
+		})
+	}
+}
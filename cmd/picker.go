@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// pickerMaxRows is the maximum number of options shown at once by the interactive picker.
+const pickerMaxRows = 10
+
+// Key sequences understood by the interactive picker
+var (
+	keyUp   = []byte("\x1b[A")
+	keyDown = []byte("\x1b[B")
+)
+
+const (
+	keyCtrlC     = 3
+	keyCtrlH     = 8
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEnter     = '\r'
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// isInteractive returns true if both stdin and stdout are terminals.
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// pick shows an interactive fuzzy finder over the given options and returns the option the user chose. If preview is
+// not nil, it is called to get lines describing the highlighted option. If custom is true and no option matches the
+// query when enter is pressed, the query itself is returned. ok is false if the user cancelled.
+func pick(prompt string, options []string, preview func(option string) []string, custom bool) (string, bool, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", false, fmt.Errorf("error setting up terminal: %v", err)
+	}
+	defer term.Restore(fd, state)
+
+	// Hide the cursor while the picker is shown
+	fmt.Print("\x1b[?25l")
+	defer fmt.Print("\x1b[?25h")
+
+	var (
+		query    []rune
+		selected int
+		rendered int
+		buf      = make([]byte, 64)
+	)
+	for {
+		matches := fuzzyRank(string(query), options)
+		if selected >= len(matches) {
+			selected = len(matches) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+
+		rendered = renderPicker(rendered, prompt, string(query), matches, selected, preview, custom)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			clearPicker(rendered)
+			return "", false, err
+		}
+		key := buf[:n]
+
+		switch {
+		case n == 1 && (key[0] == keyCtrlC || key[0] == keyEscape):
+			clearPicker(rendered)
+			return "", false, nil
+
+		case key[0] == keyEnter || key[0] == '\n':
+			if len(matches) > 0 {
+				clearPicker(rendered)
+				return matches[selected], true, nil
+			} else if custom && len(query) > 0 {
+				clearPicker(rendered)
+				return string(query), true, nil
+			}
+
+		case bytes.Equal(key, keyUp) || key[0] == keyCtrlP:
+			selected--
+
+		case bytes.Equal(key, keyDown) || key[0] == keyCtrlN:
+			selected++
+
+		case key[0] == keyBackspace || key[0] == keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				selected = 0
+			}
+
+		case key[0] == keyCtrlU:
+			query = nil
+			selected = 0
+
+		case key[0] >= ' ' && key[0] != keyEscape:
+			query = append(query, []rune(string(key))...)
+			selected = 0
+		}
+	}
+}
+
+// renderPicker draws the picker, replacing the given number of previously rendered lines. It returns the number of
+// lines drawn.
+func renderPicker(
+	rendered int,
+	prompt string,
+	query string,
+	matches []string,
+	selected int,
+	preview func(option string) []string,
+	custom bool,
+) int {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	lines := []string{fmt.Sprintf("%s: %s\x1b[7m \x1b[0m", prompt, query)}
+
+	// Only show a window of options around the selected one
+	rows := pickerMaxRows
+	if rows > height-8 {
+		rows = height - 8
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	offset := 0
+	if selected >= rows {
+		offset = selected - rows + 1
+	}
+
+	for i := offset; i < len(matches) && i < offset+rows; i++ {
+		if i == selected {
+			lines = append(lines, "\x1b[7m> "+truncate(matches[i], width-2)+"\x1b[0m")
+		} else {
+			lines = append(lines, "  "+truncate(matches[i], width-2))
+		}
+	}
+
+	switch {
+	case len(matches) == 0 && custom && query != "":
+		lines = append(lines, fmt.Sprintf(`  (press enter to use "%s")`, truncate(query, width-25)))
+	case len(matches) == 0:
+		lines = append(lines, "  (no matches)")
+	case len(matches) > rows:
+		lines = append(lines, fmt.Sprintf("  (%d/%d)", selected+1, len(matches)))
+	}
+
+	if preview != nil && len(matches) > 0 {
+		lines = append(lines, "  "+strings.Repeat("-", 20))
+		for _, line := range preview(matches[selected]) {
+			lines = append(lines, "  "+truncate(line, width-2))
+		}
+	}
+
+	clearPicker(rendered)
+	fmt.Print(strings.Join(lines, "\r\n"))
+	return len(lines)
+}
+
+// clearPicker erases the given number of previously rendered lines, leaving the cursor where the first one began.
+func clearPicker(rendered int) {
+	if rendered == 0 {
+		return
+	}
+
+	fmt.Print("\r")
+	if rendered > 1 {
+		fmt.Printf("\x1b[%dA", rendered-1)
+	}
+	fmt.Print("\x1b[J")
+}
+
+// truncate shortens the given string to at most the given number of characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if n < 1 {
+		return ""
+	}
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// pingConcurrency is how many clusters are pinged at the same time.
+const pingConcurrency = 16
+
+const pingExample = `
+  ks ping                     # check the cluster of the current context
+  ks ping prod-eks staging    # check the clusters of several contexts
+  ks ping --all --timeout 2s  # check all clusters, giving each two seconds to answer
+  ks list --health            # list contexts along with the status of their clusters
+`
+
+// Statuses of pinged clusters
+const (
+	pingOK            = "ok"
+	pingNotReady      = "not ready"
+	pingUnreachable   = "unreachable"
+	pingTLSError      = "tls error"
+	pingAuthError     = "auth error"
+	pingInvalidConfig = "invalid config"
+	pingError         = "error"
+)
+
+// pingResult describes how the cluster of a context responded to a ping. The JSON names of its fields are part of the
+// output of "ks list --health -o json", so they must not change.
+type pingResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// pingCmd represents the ping command
+var pingCmd = &cobra.Command{
+	Use:               "ping [context...]",
+	ValidArgsFunction: completeContexts,
+	Short:             "Check whether the clusters of contexts are reachable and healthy",
+	Long: `This command checks the clusters of the given contexts, or of the current context if none are given, by requesting
+/version and /readyz from their API servers with the context's credentials. All clusters are checked at the same time,
+and each gets --timeout to answer.
+
+For every context, the status, the latency of the /version request, the server version and any error are shown. The
+status is one of:
+
+  ok              the API server answered and is ready
+  not ready       the API server answered, but /readyz reported a problem
+  unreachable     the API server could not be reached in time, e.g. because the cluster no longer exists
+  tls error       the API server's certificate could not be verified
+  auth error      the API server rejected the context's credentials
+  invalid config  the context's cluster or user is missing or broken
+  error           anything else
+
+The exit code is 1 if any cluster is not ok. Contexts can be given by a unique prefix, substring or fuzzy match of their
+name unless --exact is used, or selected by their labels with -l.
+`,
+	Example: strings.TrimLeft(pingExample, "\n"),
+	Run: func(cmd *cobra.Command, args []string) {
+		flagTimeout, err := cmd.Flags().GetDuration("timeout")
+		handleFatalf(err, "Error reading flag timeout: %v", err)
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		names := resolveContextsAndSelector(cmd, conf, args)
+
+		if getBoolFlag(cmd, "all") {
+			names = sortedKeys(conf.Contexts)
+		} else if len(names) == 0 {
+			if _, ok := conf.Contexts[conf.CurrentContext]; !ok {
+				fatalf("No current context. Give the contexts to check, or use --all.")
+			}
+			names = []string{conf.CurrentContext}
+		}
+
+		results := pingContexts(conf, names, flagTimeout)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "CONTEXT\tSTATUS\tLATENCY\tVERSION\tERROR")
+		failed := false
+		for i, name := range names {
+			r := results[i]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, r.Status, r.Latency, r.Version, r.Error)
+			failed = failed || r.Status != pingOK
+		}
+		err = w.Flush()
+		handleFatalf(err, "Error printing results: %v", err)
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pingCmd)
+	pingCmd.Flags().Bool("all", false, "Check the clusters of all contexts")
+	pingCmd.Flags().Duration("timeout", clusterTimeout, "How long to wait for each cluster to answer")
+	pingCmd.Flags().Bool("exact", false, "Only accept exact context names")
+	pingCmd.Flags().StringP("selector", "l", "", "Also check contexts with matching labels (e.g. env=dev)")
+}
+
+// pingContexts pings the clusters of the contexts with the given names concurrently, giving each the given timeout. The
+// results are in the same order as the names.
+func pingContexts(conf *api.Config, names []string, timeout time.Duration) []pingResult {
+	results := make([]pingResult, len(names))
+	slots := make(chan struct{}, pingConcurrency)
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		restConf, err := restConfigFor(conf, name)
+		if err != nil {
+			results[i] = pingResult{Status: pingInvalidConfig, Error: err.Error()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, restConf *rest.Config) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			results[i] = pingCluster(ctx, restConf)
+		}(i, restConf)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// pingCluster requests /version and /readyz from the API server described by the given config at the same time, and
+// reports how it responded.
+func pingCluster(ctx context.Context, restConf *rest.Config) pingResult {
+	c, err := newClusterClient(restConf)
+	if err != nil {
+		return pingResult{Status: pingInvalidConfig, Error: err.Error()}
+	}
+
+	readyErr := make(chan error, 1)
+	go func() {
+		readyErr <- c.readyz(ctx)
+	}()
+
+	start := time.Now()
+	info, err := c.version(ctx)
+	latency := time.Since(start)
+	if err != nil {
+		return pingResult{Status: pingStatus(err), Error: pingMessage(err)}
+	}
+
+	r := pingResult{Status: pingOK, Latency: formatLatency(latency), Version: info.GitVersion}
+
+	// Servers older than /readyz don't know it, which says nothing about their health
+	if err = <-readyErr; err != nil && !apierrors.IsNotFound(err) {
+		r.Status = pingStatus(err)
+		r.Error = pingMessage(err)
+		if r.Status == pingError {
+			r.Status = pingNotReady
+			if failed := failedChecks(err); failed != "" {
+				r.Error = failed
+			}
+		}
+	}
+	return r
+}
+
+// failedChecks returns the checks reported as failed in the response body of a failed /readyz request, e.g.
+// "[-]etcd failed: reason withheld".
+func failedChecks(err error) string {
+	var statusErr *apierrors.StatusError
+	if !errors.As(err, &statusErr) || statusErr.ErrStatus.Details == nil {
+		return ""
+	}
+
+	failed := make([]string, 0)
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		for _, line := range strings.Split(cause.Message, "\n") {
+			if strings.HasPrefix(line, "[-]") {
+				failed = append(failed, line)
+			}
+		}
+	}
+	return strings.Join(failed, "; ")
+}
+
+// pingStatus returns the status of a cluster whose API server returned the given error.
+func pingStatus(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalidCert      x509.CertificateInvalidError
+		verification     *tls.CertificateVerificationError
+		recordHeader     tls.RecordHeaderError
+		opErr            *net.OpError
+		dnsErr           *net.DNSError
+	)
+
+	switch {
+	case apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err):
+		return pingAuthError
+	case errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalidCert) ||
+		errors.As(err, &verification) || errors.As(err, &recordHeader):
+		return pingTLSError
+	case errors.As(err, &opErr) || errors.As(err, &dnsErr) || errors.Is(err, context.DeadlineExceeded) ||
+		os.IsTimeout(err):
+		return pingUnreachable
+	default:
+		return pingError
+	}
+}
+
+// pingMessage returns a one-line description of the given error, leaving out the request URL that the HTTP client adds.
+func pingMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return strings.Join(strings.Fields(err.Error()), " ")
+}
+
+// formatLatency formats the given latency in milliseconds.
+func formatLatency(latency time.Duration) string {
+	if latency < time.Millisecond {
+		return "<1ms"
+	}
+	return latency.Round(time.Millisecond).String()
+}
+
+// String summarizes the result, e.g. "ok, 23ms, v1.27.1".
+func (r pingResult) String() string {
+	parts := []string{r.Status}
+	for _, part := range []string{r.Latency, r.Version, r.Error} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
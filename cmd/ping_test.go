@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPingContexts(t *testing.T) {
+	tests := []struct {
+		name string
+		// readyz handles /readyz, /version always answers unless the server is closed or too slow
+		readyz       http.HandlerFunc
+		unauthorized bool
+		withoutCA    bool
+		closed       bool
+		slow         bool
+		wantStatus   string
+		wantError    string
+	}{
+		{
+			name:       "ok",
+			readyz:     func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) },
+			wantStatus: pingOK,
+		},
+		{
+			name: "not ready",
+			readyz: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("[+]ping ok\n[-]etcd failed: reason withheld\nreadyz check failed\n"))
+			},
+			wantStatus: pingNotReady,
+			wantError:  "[-]etcd failed: reason withheld",
+		},
+		{
+			name:       "readyz unknown to old servers",
+			readyz:     func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+			wantStatus: pingOK,
+		},
+		{
+			name:         "auth error",
+			unauthorized: true,
+			wantStatus:   pingAuthError,
+			wantError:    "Unauthorized",
+		},
+		{
+			name:       "tls error",
+			withoutCA:  true,
+			wantStatus: pingTLSError,
+			wantError:  "certificate",
+		},
+		{
+			name:       "unreachable",
+			closed:     true,
+			wantStatus: pingUnreachable,
+			wantError:  "connection refused",
+		},
+		{
+			name:       "timeout",
+			slow:       true,
+			wantStatus: pingUnreachable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			done := make(chan struct{})
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case test.slow:
+					<-done
+				case test.unauthorized:
+					writeStatus(w, http.StatusUnauthorized, metav1.StatusReasonUnauthorized, "Unauthorized")
+				case r.URL.Path == "/version":
+					_, _ = w.Write([]byte(`{"major":"1","minor":"27","gitVersion":"v1.27.1"}`))
+				case r.URL.Path == "/readyz":
+					test.readyz(w, r)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+			defer close(done)
+
+			conf := testKubeconfig(srv, "", test.withoutCA)
+			if test.closed {
+				srv.Close()
+			}
+
+			r := pingContexts(conf, []string{"test"}, 200*time.Millisecond)[0]
+			if r.Status != test.wantStatus {
+				t.Fatalf("got status %q (%s), want %q", r.Status, r.Error, test.wantStatus)
+			}
+			if !strings.Contains(r.Error, test.wantError) {
+				t.Errorf("got error %q, want it to contain %q", r.Error, test.wantError)
+			}
+			if r.Status == pingOK && (r.Version != "v1.27.1" || r.Latency == "") {
+				t.Errorf("expected version and latency, got %+v", r)
+			}
+		})
+	}
+}
+
+func TestPingContextsInvalidConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	conf := testKubeconfig(srv, "", false)
+	delete(conf.Clusters, "test-cluster")
+
+	results := pingContexts(conf, []string{"test", "missing"}, time.Second)
+	for i, r := range results {
+		if r.Status != pingInvalidConfig {
+			t.Errorf("result %d: got status %q, want %q", i, r.Status, pingInvalidConfig)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const promptExample = `
+  PS1='[$(ks prompt --short --color bash)] \$ '          # bash
+  ks prompt --format '{{.Context}}{{if .Protected}} !{{end}}'  # mark protected contexts
+`
+
+// colorCodes maps the color names supported by ks prompt to ANSI escape codes.
+var colorCodes = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+}
+
+// promptEscapes holds the strings that tell each supported shell where non-printing characters in the prompt begin and
+// end, so the shell can calculate the width of the prompt correctly.
+var promptEscapes = map[string][2]string{
+	"ansi": {"", ""},
+	"bash": {`\[`, `\]`},
+	"zsh":  {"%{", "%}"},
+}
+
+var (
+	// eksARN matches the ARNs EKS uses as context names, capturing the name of the cluster.
+	eksARN = regexp.MustCompile(`^arn:aws[a-z-]*:eks:[^:]+:[0-9]+:cluster/(.+)$`)
+	// gkeName matches the names gcloud gives GKE contexts (gke_<project>_<location>_<cluster>), capturing the name of
+	// the cluster.
+	gkeName = regexp.MustCompile(`^gke_[^_]+_[^_]+_(.+)$`)
+)
+
+// promptData holds the fields available to the template used by ks prompt.
+type promptData struct {
+	Context   string
+	Namespace string
+	Cluster   string
+	User      string
+	Protected bool
+	Labels    map[string]string
+}
+
+// promptCmd represents the prompt command
+var promptCmd = &cobra.Command{
+	Use:               "prompt",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Print the current context and namespace for use in shell prompts",
+	Long: `This command prints the current context and namespace, formatted for use in a shell prompt like PS1 or a
+starship custom module. It only reads the current kubeconfig and never merges kubeconfig files from KSPATH, so it is
+fast enough to run on every prompt. Nothing is printed if there is no current context, and warnings and errors go to
+stderr.
+
+The output is formed by a Go template given with --format. Available fields are .Context, .Namespace, .Cluster, .User,
+.Protected and .Labels. With --short, long EKS ARNs and GKE context names are shortened to the name of the cluster.
+
+With --color, the output is colored according to the prompt colors in ${HOME}/.ks/settings.yaml, and protected
+contexts are red unless another color matches. Use "bash" or "zsh" in prompts so the shell can tell how wide the
+prompt is, or "ansi" anywhere else.
+`,
+	Example: strings.TrimLeft(promptExample, "\n"),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Only the formatted segment may end up in the prompt
+		messageOut = os.Stderr
+
+		// Merging is too slow for prompts, but time in protected contexts should still run out
+		revertExpiredSwitch()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		flagFormat := getStringFlag(cmd, "format")
+		flagShort := getBoolFlag(cmd, "short")
+		flagColor := getStringFlag(cmd, "color")
+
+		tmpl, err := template.New("prompt").Parse(flagFormat)
+		handleFatalf(err, "Error parsing format: %v", err)
+
+		escapes, ok := promptEscapes[flagColor]
+		if flagColor != "" && !ok {
+			fatalf("Unsupported color mode %s (supported modes: %s)", flagColor, strings.Join(sortedKeys(promptEscapes), ", "))
+		}
+
+		// Only read the current kubeconfig, including the session file in session mode
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		ctx, ok := conf.Contexts[conf.CurrentContext]
+		if !ok {
+			return
+		}
+
+		s, err := loadSettings()
+		handleFatalf(err, "Error loading settings: %v", err)
+		l, err := loadLabels()
+		handleFatalf(err, "Error loading labels: %v", err)
+		protected, err := s.isProtected(conf.CurrentContext)
+		handleFatalf(err, "Error checking whether context %s is protected: %v", conf.CurrentContext, err)
+
+		data := promptData{
+			Context:   conf.CurrentContext,
+			Namespace: ctx.Namespace,
+			Cluster:   ctx.Cluster,
+			User:      ctx.AuthInfo,
+			Protected: protected,
+			Labels:    l[conf.CurrentContext],
+		}
+		if data.Namespace == "" {
+			data.Namespace = "default"
+		}
+		if flagShort {
+			data.Context = shortContextName(data.Context)
+		}
+
+		var sb strings.Builder
+		err = tmpl.Execute(&sb, data)
+		handleFatalf(err, "Error formatting prompt: %v", err)
+
+		output := sb.String()
+		if flagColor != "" {
+			if code := promptColorCode(s, data); code != "" {
+				output = escapes[0] + "\x1b[" + code + "m" + escapes[1] + output + escapes[0] + "\x1b[0m" + escapes[1]
+			}
+		}
+		fmt.Print(output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.Flags().StringP("format", "f", "{{.Context}}:{{.Namespace}}", "Go template used to format the output")
+	promptCmd.Flags().BoolP("short", "s", false, "Shorten EKS ARNs and GKE context names to the cluster name")
+	promptCmd.Flags().String("color", "", "Color the output for the given shell ("+strings.Join(sortedKeys(promptEscapes), ", ")+")")
+	_ = promptCmd.RegisterFlagCompletionFunc("color", cobra.FixedCompletions(sortedKeys(promptEscapes), cobra.ShellCompDirectiveNoFileComp))
+}
+
+// shortContextName shortens EKS ARNs and GKE context names to the name of the cluster. Other names are returned as is.
+func shortContextName(name string) string {
+	for _, re := range []*regexp.Regexp{eksARN, gkeName} {
+		if m := re.FindStringSubmatch(name); m != nil {
+			return m[1]
+		}
+	}
+	return name
+}
+
+// promptColorCode returns the ANSI color code for the prompt, or an empty string if the prompt shouldn't be colored.
+func promptColorCode(s *settings, data promptData) string {
+	if s.Prompt != nil {
+		for _, c := range s.Prompt.Colors {
+			if c.selector.Matches(labels.Set(data.Labels)) {
+				return colorCodes[c.Color]
+			}
+		}
+	}
+
+	if data.Protected {
+		return colorCodes["red"]
+	}
+	return ""
+}
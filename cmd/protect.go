@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// protectedSwitch records a switch to a protected context, so it can be reverted once it expires.
+type protectedSwitch struct {
+	Context           string    `json:"context"`
+	PreviousContext   string    `json:"previousContext"`
+	PreviousNamespace string    `json:"previousNamespace,omitempty"`
+	Session           string    `json:"session,omitempty"`
+	Expires           time.Time `json:"expires"`
+}
+
+// isProtected returns true if the context with the given name is protected according to the given settings.
+func (s *settings) isProtected(ctxName string) (bool, error) {
+	p := s.Protected
+	if p == nil {
+		return false, nil
+	}
+
+	for _, re := range p.patterns {
+		if re.MatchString(ctxName) {
+			return true, nil
+		}
+	}
+
+	if p.selector != nil {
+		l, err := loadLabels()
+		if err != nil {
+			return false, err
+		}
+		if p.selector.Matches(labels.Set(l[ctxName])) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// contextProtected returns true if the context with the given name is protected. Errors loading settings or labels are
+// only reported as warnings, treating the context as unprotected.
+func contextProtected(ctxName string) bool {
+	s, err := loadSettings()
+	if err != nil {
+		warnf("Error loading settings: %v", err)
+		return false
+	}
+
+	protected, err := s.isProtected(ctxName)
+	if err != nil {
+		warnf("Error checking whether context %s is protected: %v", ctxName, err)
+		return false
+	}
+	return protected
+}
+
+// confirm asks the user the given yes/no question on the terminal and returns true if they answered yes.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// colorEnabled returns true if colored output should be written to the given file.
+func colorEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// protectedBanner returns a banner announcing that the context with the given name is protected.
+func protectedBanner(ctxName string) string {
+	text := fmt.Sprintf(" PROTECTED CONTEXT: %s ", ctxName)
+	if !colorEnabled(os.Stdout) {
+		return "!!!" + text + "!!!"
+	}
+	// Bold white on red
+	return "\x1b[1;97;41m" + text + "\x1b[0m"
+}
+
+// guardProtected makes sure the user really wants to do the given action with the protected context with the given
+// name, e.g. "switch to it". If they already confirmed with --yes, nothing is asked. Otherwise they are asked on the
+// terminal, and ks exits with an error if they decline or can't be asked.
+func guardProtected(ctxName string, action string, yes bool) {
+	if yes {
+		return
+	}
+
+	if !isInteractive() {
+		fatalf("Context %s is protected. Use --yes to %s anyway.", ctxName, action)
+	}
+	if !confirm(fmt.Sprintf("Context %s is protected. Really %s?", ctxName, action)) {
+		fatalf("Cancelled.")
+	}
+}
+
+// scheduleRevert records that the current context should go back to the given previous context and namespace once the
+// revertAfter duration from the settings has passed. Any previously scheduled revert is cancelled.
+func scheduleRevert(ctxName, prevCtxName, prevNs string) error {
+	s, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	if s.Protected == nil || s.Protected.revertAfter == 0 || prevCtxName == "" || prevCtxName == ctxName {
+		return cancelRevert()
+	}
+
+	p := &protectedSwitch{
+		Context:           ctxName,
+		PreviousContext:   prevCtxName,
+		PreviousNamespace: prevNs,
+		Session:           sessionPath(),
+		Expires:           time.Now().Add(s.Protected.revertAfter),
+	}
+	if err = writeYAMLFile(protectedPath, p); err != nil {
+		return err
+	}
+
+	infof("Switching back to %s at %s.", prevCtxName, p.Expires.Format("15:04:05"))
+	return nil
+}
+
+// cancelRevert cancels any scheduled revert.
+func cancelRevert() error {
+	return os.RemoveAll(protectedPath)
+}
+
+// revertExpiredSwitch switches back to the previous context if a scheduled revert has expired and the protected context
+// is still the current one. Reverts scheduled in another shell session are left alone.
+func revertExpiredSwitch() {
+	p := &protectedSwitch{}
+	if err := readYAMLFile(protectedPath, p); err != nil {
+		warnf("Error loading scheduled revert: %v", err)
+		return
+	}
+	if p.Context == "" || time.Now().Before(p.Expires) || p.Session != sessionPath() {
+		return
+	}
+
+	// Whatever happens now, the revert shouldn't be attempted again
+	defer func() {
+		if err := cancelRevert(); err != nil {
+			warnf("Error removing %s: %v", protectedPath, err)
+		}
+	}()
+
+	conf, err := loadKubeconfig(splitKubeconfig(os.Getenv("KUBECONFIG")))
+	if err != nil {
+		warnf("Error loading config: %v", err)
+		return
+	}
+	if conf.CurrentContext != p.Context {
+		return
+	}
+
+	ctx, ok := conf.Contexts[p.PreviousContext]
+	if !ok {
+		warnf("Can't switch back from protected context %s: context %s no longer exists.", p.Context, p.PreviousContext)
+		return
+	}
+
+	ctx.Namespace = p.PreviousNamespace
+	if err = writeCurrentContext(conf, p.PreviousContext, ctx); err != nil {
+		warnf("Error switching back from protected context %s: %v", p.Context, err)
+		return
+	}
+	warnf(`Time in protected context %s is up. Switched back to context "%s" (namespace: "%s").`, p.Context,
+		p.PreviousContext, p.PreviousNamespace)
+}
+
+// writeCurrentContext makes the given context with the given name the current one. In session mode, only the current
+// shell's session file is changed, and inside "ks shell" only the private copy.
+func writeCurrentContext(conf *api.Config, ctxName string, ctx *api.Context) error {
+	conf.CurrentContext = ctxName
+	conf.Contexts[ctxName] = ctx
+
+	if path := shellConfigPath(); path != "" {
+		return writeKubeconfig(path, conf)
+	}
+	if sessionPath() != "" {
+		return updateSession(func(session *api.Config) {
+			session.CurrentContext = ctxName
+			session.Contexts[ctxName] = ctx
+		})
+	}
+	return writeKubeconfig(masterConfigPath, conf)
+}
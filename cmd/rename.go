@@ -4,22 +4,26 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 // renameCmd represents the rename command
 var renameCmd = &cobra.Command{
-	Use:     "rename <old-name> <new-name>",
-	Aliases: []string{"r"},
-	Args:    cobra.ExactArgs(2),
-	Short:   "Rename an existing context",
+	Use:               "rename <old-name> <new-name>",
+	Aliases:           []string{"r"},
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeFirstContext,
+	Short:             "Rename an existing context",
 	Long: `This command changes the name assigned to an existing context in the kubeconfig pointed to by the KUBECONFIG
 env var. If this context is the current context, the current context will be also updated.
+
+The old name can be a unique prefix, substring or fuzzy match of the context's name unless --exact is used.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		argOldName, argNewName := args[0], args[1]
 
 		// Load kubeconfig from file
-		confPath := os.Getenv("KUBECONFIG")
+		confPath := kubeconfigTarget(os.Getenv("KUBECONFIG"))
 		if confPath == "" {
 			fatalf("KUBECONFIG is not set. Please make sure KUBECONFIG is set correctly.")
 		}
@@ -33,10 +37,9 @@ env var. If this context is the current context, the current context will be als
 		)
 
 		// Make sure a context exists with the old name
-		ctx, exists := conf.Contexts[argOldName]
-		if !exists {
-			fatalf(`No context exists with name %s`, argOldName)
-		}
+		argOldName, err = resolveContext(conf, argOldName, getBoolFlag(cmd, "exact"))
+		handleFatalf(err, "Error finding context: %v", err)
+		ctx := conf.Contexts[argOldName]
 
 		// Make sure no context exists with the new name
 		if _, conflict := conf.Contexts[argNewName]; conflict {
@@ -56,10 +59,46 @@ env var. If this context is the current context, the current context will be als
 		err = writeKubeconfig(confPath, conf)
 		handleFatalf(err, "Error writing config to %s: %v", confPath, err)
 
+		// Rename the context in the current shell's session too
+		err = updateSession(func(session *api.Config) {
+			if sessionCtx, ok := session.Contexts[argOldName]; ok {
+				delete(session.Contexts, argOldName)
+				session.Contexts[argNewName] = sessionCtx
+			}
+			if session.CurrentContext == argOldName {
+				session.CurrentContext = argNewName
+			}
+		})
+		handleFatalf(err, "Error updating session: %v", err)
+
+		// Make sure the rename survives the next merge
+		updateOverlay(confPath, func(o *overlay) {
+			o.recordRename(argOldName, argNewName)
+		})
+
+		// Keep aliases pointing at the context
+		a, err := loadAliases()
+		handleFatalf(err, "Error loading aliases: %v", err)
+		if a.renameContext(argOldName, argNewName) {
+			err = a.save()
+			handleFatalf(err, "Error writing aliases: %v", err)
+		}
+
+		// Labels belong to the context, not its name
+		l, err := loadLabels()
+		handleFatalf(err, "Error loading labels: %v", err)
+		if ctxLabels, ok := l[argOldName]; ok {
+			delete(l, argOldName)
+			l[argNewName] = ctxLabels
+			err = l.save()
+			handleFatalf(err, "Error writing labels: %v", err)
+		}
+
 		infof("Context %s renamed to %s.", argOldName, argNewName)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(renameCmd)
+	renameCmd.Flags().Bool("exact", false, "Only accept the exact old name")
 }
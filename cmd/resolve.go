@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// maxCandidates is the maximum number of candidates listed when a context name is ambiguous.
+const maxCandidates = 10
+
+// resolveContext returns the name of the context in the given config that the given query refers to. An exact match
+// always wins. Otherwise, the query must be a unique prefix, a unique substring or a unique fuzzy match of a context
+// name, in that order. If exact is true, only exact matches are accepted.
+func resolveContext(conf *api.Config, query string, exact bool) (string, error) {
+	if _, ok := conf.Contexts[query]; ok {
+		return query, nil
+	}
+	if exact || query == "" {
+		return "", fmt.Errorf("no such context: %s", query)
+	}
+
+	// Group matches by tier, so a unique match in a better tier wins over any number of worse matches
+	ranked := fuzzyRank(query, sortedKeys(conf.Contexts))
+	for _, tier := range []matchTier{exactMatch, prefixMatch, substringMatch, fuzzyMatch} {
+		var matches []string
+		for _, name := range ranked {
+			if t, _ := fuzzyMatchScore(query, name); t == tier {
+				matches = append(matches, name)
+			}
+		}
+
+		switch {
+		case len(matches) == 1:
+			return matches[0], nil
+		case len(matches) > 1:
+			return "", ambiguousContextError(query, matches)
+		}
+	}
+
+	return "", fmt.Errorf("no such context: %s", query)
+}
+
+// ambiguousContextError returns an error listing the given candidates for an ambiguous query, best matches first.
+func ambiguousContextError(query string, ranked []string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `"%s" matches multiple contexts:`, query)
+	for i, name := range ranked {
+		if i == maxCandidates {
+			fmt.Fprintf(&sb, "\n  ... and %d more", len(ranked)-maxCandidates)
+			break
+		}
+		sb.WriteString("\n  " + name)
+	}
+	sb.WriteString("\nUse a longer name to pick one.")
+	return errors.New(sb.String())
+}
+
+// resolveContexts resolves each of the given queries using resolveContext.
+func resolveContexts(conf *api.Config, queries []string, exact bool) ([]string, error) {
+	names := make([]string, len(queries))
+	for i, query := range queries {
+		name, err := resolveContext(conf, query, exact)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = name
+	}
+	return names, nil
+}
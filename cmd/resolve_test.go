@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestResolveContext(t *testing.T) {
+	conf := api.NewConfig()
+	for _, name := range []string{"dev", "devops", "payments-api", "prod-eks", "prod-gke", "staging-eks"} {
+		conf.Contexts[name] = api.NewContext()
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		exact bool
+		want  string
+		// wantErr is part of the expected error message, if an error is expected
+		wantErr string
+	}{
+		{name: "exact beats prefix", query: "dev", want: "dev"},
+		{name: "case-insensitive", query: "PROD-EKS", want: "prod-eks"},
+		{name: "case-insensitive beats prefix", query: "DEV", want: "dev"},
+		{name: "unique prefix", query: "stag", want: "staging-eks"},
+		{
+			name:    "ambiguous prefix",
+			query:   "prod",
+			wantErr: `"prod" matches multiple contexts:` + "\n  prod-eks\n  prod-gke",
+		},
+		{name: "unique substring", query: "gke", want: "prod-gke"},
+		{name: "prefix beats substring", query: "devo", want: "devops"},
+		{name: "ambiguous substring", query: "eks", wantErr: "matches multiple contexts"},
+		{name: "unique fuzzy match", query: "pmtapi", want: "payments-api"},
+		{name: "ambiguous fuzzy match", query: "pe", wantErr: "matches multiple contexts"},
+		{name: "no match", query: "xyz", wantErr: "no such context: xyz"},
+		{name: "empty query", query: "", wantErr: "no such context"},
+		{name: "exact only accepts exact names", query: "dev", exact: true, want: "dev"},
+		{name: "exact rejects prefixes", query: "stag", exact: true, wantErr: "no such context: stag"},
+		{name: "exact rejects other case", query: "DEV", exact: true, wantErr: "no such context: DEV"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := resolveContext(conf, test.query, test.exact)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got %q and error %v, want an error containing %q", got, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveContexts(t *testing.T) {
+	conf := api.NewConfig()
+	for _, name := range []string{"prod-eks", "staging-eks"} {
+		conf.Contexts[name] = api.NewContext()
+	}
+
+	names, err := resolveContexts(conf, []string{"stag", "prod"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "staging-eks" || names[1] != "prod-eks" {
+		t.Errorf("got %v, want [staging-eks prod-eks]", names)
+	}
+
+	// Nothing is returned if any query fails, so commands never act on part of what was asked for
+	if names, err = resolveContexts(conf, []string{"stag", "eks"}, false); err == nil || names != nil {
+		t.Errorf("got %v and error %v, want an error", names, err)
+	}
+}
@@ -12,7 +12,16 @@ var (
 	homeDir          string
 	ksHomeDir        string
 	masterConfigPath string
-	initPath         string
+	overlayPath      string
+	manifestPath     string
+	settingsPath     string
+	sessionsDir      string
+	originsPath      string
+	historyPath      string
+	nsCachePath      string
+	aliasesPath      string
+	labelsPath       string
+	protectedPath    string
 	kubeconfigPaths  []string
 )
 
@@ -33,6 +42,14 @@ listed there will persist unless changed manually.
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) {},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Completion has to be fast, so it works with whatever was merged last
+		if isCompletion(cmd) {
+			return
+		}
+		syncMasterConfig(getBoolFlag(cmd, "refresh"))
+		revertExpiredSwitch()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -49,8 +66,23 @@ func init() {
 	homeDir = homedir.HomeDir()
 	ksHomeDir = homeDir + "/.ks"
 	masterConfigPath = ksHomeDir + "/config"
-	initPath = ksHomeDir + "/init.sh"
+	overlayPath = ksHomeDir + "/overlay.yaml"
+	manifestPath = ksHomeDir + "/manifest.yaml"
+	settingsPath = ksHomeDir + "/settings.yaml"
+	sessionsDir = ksHomeDir + "/sessions"
+	originsPath = ksHomeDir + "/origins.yaml"
+	historyPath = ksHomeDir + "/history.yaml"
+	nsCachePath = ksHomeDir + "/namespaces.yaml"
+	aliasesPath = ksHomeDir + "/aliases.yaml"
+	labelsPath = ksHomeDir + "/labels.yaml"
+	protectedPath = ksHomeDir + "/protected.yaml"
+
+	rootCmd.PersistentFlags().Bool("refresh", false, "Ignore cached state and rebuild the merged kubeconfig")
+}
 
+// syncMasterConfig merges all kubeconfig files from KSPATH into the master config file. The merge is skipped if none
+// of the files involved have changed since the last merge, unless refresh is true.
+func syncMasterConfig(refresh bool) {
 	// Abort if we're not initialized (i.e. the .ks directory doesn't exist)
 	info, err := os.Stat(ksHomeDir)
 	if err != nil {
@@ -71,12 +103,25 @@ func init() {
 		ksPath = "~/.kube"
 	}
 
+	// Parse paths from envvar, appending the master config file if it exists
+	kubeconfigPaths = ksPathEntries(ksPath)
+	prevManifest, err := loadManifest()
+	handleFatalf(err, "Error loading manifest: %v", err)
+
+	// Skip the merge if nothing has changed since the last one
+	m, err := buildManifest(ksPath, kubeconfigPaths, syncExtras(), prevManifest)
+	handleFatalf(err, "Error loading config: %v", err)
+	if !refresh && m.equal(prevManifest) {
+		return
+	}
+
 	// First we need to get the current context and namespace, so we can make sure not to overwrite it later
 	var (
 		currentCtxName string
 		currentNs      string
 	)
-	if existingConfPath := os.Getenv("KUBECONFIG"); existingConfPath != "" {
+	// Session files are deliberately ignored here, since they only apply to the shell they belong to.
+	if existingConfPath := sharedKubeconfigTarget(); existingConfPath != "" {
 		// Make sure the file still exists before trying to load it. If it doesn't we'll just skip this step since
 		// there is no current context in this case.
 		_, err = os.Stat(existingConfPath)
@@ -95,25 +140,23 @@ func init() {
 		}
 	}
 
-	// Parse paths from envvar, appending the master config file if it exists
-	kubeconfigPaths = strings.Split(ksPath, ":")
-	_, err = os.Stat(masterConfigPath)
-	if err != nil && !os.IsNotExist(err) {
-		fatalf("Error checking file %s: %v", masterConfigPath, err)
-	} else if err == nil {
-		kubeconfigPaths = append(kubeconfigPaths, masterConfigPath)
-	}
-
-	// Load kubeconfig
-	conf, err := loadKubeconfig(kubeconfigPaths)
+	// Load kubeconfig, applying naming rules from settings
+	s, err := loadSettings()
+	handleFatalf(err, "Error loading settings: %v", err)
+	conf, err := loadKubeconfigFiles(m.validSources(), s)
 	handleFatalf(err, "Error loading config: %v", err)
 
+	// Re-apply changes made by ks commands since the original files never change
+	o, err := loadOverlay()
+	handleFatalf(err, "Error loading overlay: %v", err)
+	o.apply(conf)
+
 	// Make sure we restore the current context and namespace, if the context still exists. Otherwise, print a warning
 	// message to let the user know that their current context has changed.
 	if ctx, exists := conf.Contexts[currentCtxName]; exists {
 		conf.CurrentContext = currentCtxName
 		ctx.Namespace = currentNs
-	} else {
+	} else if currentCtxName != "" {
 		var newNs string
 		if newCtx, ok := conf.Contexts[conf.CurrentContext]; ok {
 			newNs = newCtx.Namespace
@@ -126,7 +169,42 @@ func init() {
 		)
 	}
 
+	// Let the user know about aliases that were left behind by the merge
+	checkAliases(conf)
+
+	// Record where each context came from, since that is lost once they are all in the master config
+	err = writeYAMLFile(originsPath, contextOrigins(conf))
+	handleFatalf(err, "Error writing %s: %v", originsPath, err)
+
 	// Encode and write to file
 	err = writeKubeconfig(masterConfigPath, conf)
 	handleFatalf(err, "Error writing config: %v", err)
+
+	// Record the state of all inputs, including the master config file we just wrote
+	kubeconfigPaths = ksPathEntries(ksPath)
+	m, err = buildManifest(ksPath, kubeconfigPaths, syncExtras(), m)
+	handleFatalf(err, "Error fingerprinting config: %v", err)
+	err = m.save()
+	handleFatalf(err, "Error writing manifest: %v", err)
+}
+
+// ksPathEntries splits the given KSPATH value into its entries, appending the master config file if it exists.
+func ksPathEntries(ksPath string) []string {
+	paths := strings.Split(ksPath, ":")
+	_, err := os.Stat(masterConfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		fatalf("Error checking file %s: %v", masterConfigPath, err)
+	} else if err == nil {
+		paths = append(paths, masterConfigPath)
+	}
+	return paths
+}
+
+// syncExtras returns the paths of files other than those under KSPATH that affect the result of the merge.
+func syncExtras() []string {
+	extras := []string{overlayPath, settingsPath}
+	if existingConfPath := sharedKubeconfigTarget(); existingConfPath != "" && !isMasterConfig(existingConfPath) {
+		extras = append(extras, existingConfPath)
+	}
+	return extras
 }
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// sessionMaxAge is how long a session file can go unmodified before it is considered stale, regardless of whether the
+// shell that owns it is still running.
+const sessionMaxAge = 7 * 24 * time.Hour
+
+// sessionPath returns the path of the session file for the current shell, or an empty string if session mode is not
+// active in the current shell.
+func sessionPath() string {
+	id := os.Getenv("KS_SESSION")
+	if id == "" {
+		return ""
+	}
+
+	// Session mode is only active if the session file is actually in use
+	path := filepath.Join(sessionsDir, filepath.Base(id)+".yaml")
+	for _, p := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if filepath.Clean(p) == path {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// updateSession loads the session file for the current shell, applies the given change to it and writes it back. It
+// does nothing if session mode is not active. Stale session files from other shells are removed along the way.
+func updateSession(change func(session *api.Config)) error {
+	path := sessionPath()
+	if path == "" {
+		return nil
+	}
+
+	session := api.NewConfig()
+	if _, err := os.Stat(path); err == nil {
+		session, err = clientcmd.LoadFromFile(path)
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	change(session)
+
+	if err := os.MkdirAll(sessionsDir, 0755); err != nil {
+		return err
+	}
+	if err := writeKubeconfig(path, session); err != nil {
+		return err
+	}
+
+	return gcSessions()
+}
+
+// gcSessions removes session files belonging to shells that are no longer running, or that have not been modified in
+// a long time.
+func gcSessions() error {
+	entries, err := os.ReadDir(sessionsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+
+		// Session IDs are shell PIDs
+		stale := time.Since(info.ModTime()) > sessionMaxAge
+		if pid, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".yaml")); err == nil && !processAlive(pid) {
+			stale = true
+		}
+
+		if stale && filepath.Join(sessionsDir, entry.Name()) != sessionPath() {
+			if err = os.Remove(filepath.Join(sessionsDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// processAlive returns true if a process with the given PID is running. It always returns true on platforms where
+// this can't be checked cheaply, in which case stale sessions are only removed based on age.
+func processAlive(pid int) bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// settings holds user preferences read from ${HOME}/.ks/settings.yaml.
+type settings struct {
+	// Sources holds settings for specific files or directories from KSPATH.
+	Sources []*sourceSettings `json:"sources,omitempty"`
+	// Protected describes contexts that need confirmation before switching to them.
+	Protected *protectedSettings `json:"protected,omitempty"`
+	// Prompt holds settings for ks prompt.
+	Prompt *promptSettings `json:"prompt,omitempty"`
+	// Expiry holds thresholds for warnings about expiring credentials and certificates.
+	Expiry *expirySettings `json:"expiry,omitempty"`
+}
+
+// sourceSettings holds settings for kubeconfig files at a specific path from KSPATH.
+type sourceSettings struct {
+	// Path is the file or directory the settings apply to. Settings for a directory apply to all files under it.
+	Path string `json:"path"`
+	// Rewrite lists regular expression replacements applied to the names of contexts, in order.
+	Rewrite []rewriteRule `json:"rewrite,omitempty"`
+	// Template is a Go template used to form context names after rewrite rules have been applied. See contextNameData
+	// for available fields.
+	Template string `json:"template,omitempty"`
+	// Prefix is prepended to context names after the template has been applied.
+	Prefix string `json:"prefix,omitempty"`
+
+	rewrite  []*regexp.Regexp
+	template *template.Template
+}
+
+// protectedSettings describes protected contexts. A context is protected if its name matches any of the patterns or
+// its labels match the selector.
+type protectedSettings struct {
+	// Patterns lists regular expressions matched against context names.
+	Patterns []string `json:"patterns,omitempty"`
+	// Selector is a label selector matched against the labels of contexts.
+	Selector string `json:"selector,omitempty"`
+	// RevertAfter is how long to stay in a protected context before switching back to the previous one, e.g. "15m".
+	// Protected contexts are never left automatically if it is empty.
+	RevertAfter string `json:"revertAfter,omitempty"`
+
+	patterns    []*regexp.Regexp
+	selector    labels.Selector
+	revertAfter time.Duration
+}
+
+// promptSettings holds settings for ks prompt.
+type promptSettings struct {
+	// Colors lists colors for contexts with matching labels. The first matching entry wins.
+	Colors []*promptColor `json:"colors,omitempty"`
+}
+
+// promptColor is the color used by ks prompt for contexts whose labels match a selector.
+type promptColor struct {
+	Selector string `json:"selector"`
+	Color    string `json:"color"`
+
+	selector labels.Selector
+}
+
+// expirySettings holds the thresholds used by ks expiry and the warnings shown by ks switch.
+type expirySettings struct {
+	// Warning is how long before they expire to start warning about credentials and certificates, e.g. "720h".
+	Warning string `json:"warning,omitempty"`
+	// Critical is how long before they expire to treat credentials and certificates as critical, e.g. "168h".
+	Critical string `json:"critical,omitempty"`
+
+	warning  time.Duration
+	critical time.Duration
+}
+
+// rewriteRule replaces all matches of a regular expression in a context name.
+type rewriteRule struct {
+	Match   string `json:"match"`
+	Replace string `json:"replace"`
+}
+
+// loadSettings loads settings from file. Empty settings are returned if the file does not exist.
+func loadSettings() (*settings, error) {
+	s := &settings{}
+	if err := readYAMLFile(settingsPath, s); err != nil {
+		return nil, err
+	}
+
+	// Compile expressions and templates up front so mistakes are reported early
+	for _, source := range s.Sources {
+		for _, rule := range source.Rewrite {
+			re, err := regexp.Compile(rule.Match)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rewrite rule for %s: %v", source.Path, err)
+			}
+			source.rewrite = append(source.rewrite, re)
+		}
+
+		if source.Template != "" {
+			tmpl, err := template.New(source.Path).Option("missingkey=error").Parse(source.Template)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template for %s: %v", source.Path, err)
+			}
+			source.template = tmpl
+		}
+	}
+
+	if p := s.Protected; p != nil {
+		for _, pattern := range p.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid protected pattern: %v", err)
+			}
+			p.patterns = append(p.patterns, re)
+		}
+
+		if p.Selector != "" {
+			sel, err := labels.Parse(p.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid protected selector: %v", err)
+			}
+			p.selector = sel
+		}
+
+		if p.RevertAfter != "" {
+			d, err := time.ParseDuration(p.RevertAfter)
+			if err != nil {
+				return nil, fmt.Errorf("invalid revertAfter for protected contexts: %v", err)
+			}
+			p.revertAfter = d
+		}
+	}
+
+	if p := s.Prompt; p != nil {
+		for _, c := range p.Colors {
+			sel, err := labels.Parse(c.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid prompt color selector: %v", err)
+			}
+			c.selector = sel
+
+			if _, ok := colorCodes[c.Color]; !ok {
+				return nil, fmt.Errorf("invalid prompt color %s (supported colors: %s)", c.Color,
+					strings.Join(sortedKeys(colorCodes), ", "))
+			}
+		}
+	}
+
+	if e := s.Expiry; e != nil {
+		if e.Warning != "" {
+			d, err := time.ParseDuration(e.Warning)
+			if err != nil {
+				return nil, fmt.Errorf("invalid warning threshold for expiry: %v", err)
+			}
+			e.warning = d
+		}
+
+		if e.Critical != "" {
+			d, err := time.ParseDuration(e.Critical)
+			if err != nil {
+				return nil, fmt.Errorf("invalid critical threshold for expiry: %v", err)
+			}
+			e.critical = d
+		}
+	}
+
+	return s, nil
+}
+
+// expiryThresholds returns how long before they expire credentials and certificates get a warning and are treated as
+// critical, falling back to defaults for thresholds that aren't set.
+func (s *settings) expiryThresholds() (warning, critical time.Duration) {
+	warning, critical = defaultExpiryWarning, defaultExpiryCritical
+	if e := s.Expiry; e != nil {
+		if e.warning != 0 {
+			warning = e.warning
+		}
+		if e.critical != 0 {
+			critical = e.critical
+		}
+	}
+	return warning, critical
+}
+
+// sourceFor returns the settings that apply to the kubeconfig file at the given path, or nil if there are none. If
+// several entries apply, the first one wins.
+func (s *settings) sourceFor(path string) *sourceSettings {
+	path = filepath.Clean(path)
+	for _, source := range s.Sources {
+		sourcePath := filepath.Clean(expandHome(source.Path))
+		if path == sourcePath || strings.HasPrefix(path, sourcePath+string(filepath.Separator)) {
+			return source
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rcMarker marks code added to rc files by ks init.
+const rcMarker = "# Added by ks init. Do not edit."
+
+// shell generates code for a particular kind of shell.
+type shell struct {
+	name string
+	// pidVar is an expression that evaluates to the PID of the running shell.
+	pidVar string
+	// sessionVar is an expression that evaluates to the KS_SESSION env var.
+	sessionVar string
+	// export returns code that sets and exports an env var. The value is placed in double quotes as is, so literal
+	// strings in it must be escaped with escape.
+	export func(name, value string) string
+	// unset returns code that unsets an env var.
+	unset func(name string) string
+	// exportOutsideKsShell is like export, but leaves the env var alone when running inside "ks shell".
+	exportOutsideKsShell func(name, value string) string
+	// escape escapes a string for use in double quotes.
+	escape func(s string) string
+	// rcPath returns the path of the file the shell runs on startup.
+	rcPath func() string
+	// rcSnippet is the code that ks init adds to the shell's rc file. It sources the shell's activation script.
+	rcSnippet string
+	// initExt is the file extension of the shell's activation script.
+	initExt string
+	// requiresInit is true if the shell's rc file fails to load when the activation script does not exist.
+	requiresInit bool
+}
+
+// posixEscaper escapes strings for use in double quotes in POSIX-like shells.
+var posixEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+
+// fishEscaper escapes strings for use in double quotes in fish.
+var fishEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+
+// nuEscaper escapes strings for use in interpolated strings in nushell.
+var nuEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `(`, `\(`)
+
+// posixShell returns a shell with POSIX-like syntax that uses the given rc file and snippet.
+func posixShell(name string, rcPath func() string, rcSnippet string) *shell {
+	return &shell{
+		name:       name,
+		pidVar:     "$$",
+		sessionVar: "$KS_SESSION",
+		export: func(name, value string) string {
+			return fmt.Sprintf(`export %s="%s"`, name, value)
+		},
+		unset: func(name string) string {
+			return "unset " + name
+		},
+		exportOutsideKsShell: func(name, value string) string {
+			return fmt.Sprintf(`[ -n "$KS_SHELL" ] || export %s="%s"`, name, value)
+		},
+		escape:    posixEscaper.Replace,
+		rcPath:    rcPath,
+		rcSnippet: rcSnippet,
+		initExt:   "sh",
+	}
+}
+
+// homeFile returns a function that returns the path of the given file in the home directory.
+func homeFile(name string) func() string {
+	return func() string {
+		return filepath.Join(homeDir, name)
+	}
+}
+
+// configFile returns a function that returns the path of the given file in the user's config directory.
+func configFile(name string) func() string {
+	return func() string {
+		if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+			return filepath.Join(dir, name)
+		}
+		return filepath.Join(homeDir, ".config", name)
+	}
+}
+
+const (
+	bashSnippet  = `[[ -f ${HOME}/.ks/init.sh ]] && source ${HOME}/.ks/init.sh`
+	posixSnippet = `[ -f "${HOME}/.ks/init.sh" ] && . "${HOME}/.ks/init.sh"`
+)
+
+// shells holds all supported shells by name.
+var shells = map[string]*shell{
+	"bash": posixShell("bash", homeFile(".bashrc"), bashSnippet),
+	"zsh": posixShell("zsh", func() string {
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc")
+		}
+		return filepath.Join(homeDir, ".zshrc")
+	}, bashSnippet),
+	"sh": posixShell("sh", func() string {
+		if env := os.Getenv("ENV"); env != "" {
+			return expandHome(env)
+		}
+		return filepath.Join(homeDir, ".profile")
+	}, posixSnippet),
+	"ksh": posixShell("ksh", homeFile(".kshrc"), posixSnippet),
+	"fish": {
+		name:       "fish",
+		pidVar:     "$fish_pid",
+		sessionVar: "$KS_SESSION",
+		export: func(name, value string) string {
+			return fmt.Sprintf(`set -gx %s "%s"`, name, value)
+		},
+		unset: func(name string) string {
+			return "set -e " + name
+		},
+		exportOutsideKsShell: func(name, value string) string {
+			return fmt.Sprintf(`set -q KS_SHELL; or set -gx %s "%s"`, name, value)
+		},
+		escape:    fishEscaper.Replace,
+		rcPath:    configFile("fish/config.fish"),
+		rcSnippet: `test -f "$HOME/.ks/init.fish"; and source "$HOME/.ks/init.fish"`,
+		initExt:   "fish",
+	},
+	"nu": {
+		name:       "nu",
+		pidVar:     "($nu.pid)",
+		sessionVar: "($env.KS_SESSION)",
+		export: func(name, value string) string {
+			return fmt.Sprintf(`$env.%s = $"%s"`, name, value)
+		},
+		unset: func(name string) string {
+			return "hide-env -i " + name
+		},
+		exportOutsideKsShell: func(name, value string) string {
+			// Blocks don't leak env changes in nushell, so the condition has to be part of the value
+			return fmt.Sprintf(`$env.%s = (if "KS_SHELL" in $env { $env.%s? | default "" } else { $"%s" })`, name, name, value)
+		},
+		escape:       nuEscaper.Replace,
+		rcPath:       configFile("nushell/config.nu"),
+		rcSnippet:    `source ~/.ks/init.nu`,
+		initExt:      "nu",
+		requiresInit: true,
+	},
+}
+
+// shellNames returns the names of all supported shells in alphabetical order.
+func shellNames() []string {
+	return sortedKeys(shells)
+}
+
+// detectShell returns the name of the user's default shell.
+func detectShell() string {
+	name := strings.TrimPrefix(filepath.Base(os.Getenv("SHELL")), "-")
+	if name == "nushell" {
+		return "nu"
+	}
+	return name
+}
+
+// getShell returns the shell with the given name, or the user's default shell if the name is empty.
+func getShell(name string) (*shell, error) {
+	if name == "" {
+		name = detectShell()
+	}
+
+	sh, ok := shells[name]
+	if !ok {
+		return nil, fmt.Errorf(`unsupported shell "%s" (supported shells: %s)`, name, strings.Join(shellNames(), ", "))
+	}
+	return sh, nil
+}
+
+// initScriptPath returns the path of the activation script sourced by the shell's rc snippet.
+func (sh *shell) initScriptPath() string {
+	return filepath.Join(ksHomeDir, "init."+sh.initExt)
+}
+
+// activationScript returns code that points KUBECONFIG at the master config. If session is true, KUBECONFIG will
+// instead point at a session file for the running shell layered over the master config.
+func (sh *shell) activationScript(session bool) string {
+	return sh.buildActivationScript(session, sh.export)
+}
+
+// initScript returns the activation script sourced from the shell's rc file. It is the same as activationScript, except
+// that it doesn't touch shells started by "ks shell", which have their own KUBECONFIG.
+func (sh *shell) initScript(session bool) string {
+	return sh.buildActivationScript(session, sh.exportOutsideKsShell)
+}
+
+// buildActivationScript returns an activation script that uses the given function to export env vars.
+func (sh *shell) buildActivationScript(session bool, export func(name, value string) string) string {
+	if !session {
+		return export("KUBECONFIG", sh.escape(masterConfigPath)) + "\n"
+	}
+
+	kubeconfig := sh.escape(sessionsDir+string(filepath.Separator)) + sh.sessionVar + ".yaml" +
+		sh.escape(string(filepath.ListSeparator)+masterConfigPath)
+	return export("KS_SESSION", sh.pidVar) + "\n" + export("KUBECONFIG", kubeconfig) + "\n"
+}
+
+// writeActivationScripts writes the activation scripts for all shells. If active is false, the scripts are removed
+// instead, except for shells that require them to exist, which get empty scripts.
+func writeActivationScripts(active bool, session bool) error {
+	for _, name := range shellNames() {
+		sh := shells[name]
+		path := sh.initScriptPath()
+
+		if active {
+			if err := os.WriteFile(path, []byte(sh.initScript(session)), 0644); err != nil {
+				return err
+			}
+		} else if sh.requiresInit {
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				return err
+			}
+		} else if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
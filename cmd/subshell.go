@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// shellCmd represents the shell command
+var shellCmd = &cobra.Command{
+	Use:   "shell <context>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Start a subshell that uses a context without affecting other shells",
+	Long: `This command starts a new instance of the shell from the SHELL env var with KUBECONFIG pointing at a private copy
+of the current kubeconfig, with the given context and namespace selected. Switches made inside the subshell only change
+the private copy, so other shells and ${HOME}/.ks/config are unaffected. The private copy is removed when the subshell
+exits.
+
+Inside the subshell, KS_SHELL is set to the context the subshell was started with and KS_SHELL_LEVEL to how deeply
+ks shells are nested, so prompts can show them.
+
+The context can be an alias, or a unique prefix, substring or fuzzy match of a context name unless --exact is used.
+`,
+	Example:           "  ks shell prod-eks -n payments  # debug in namespace \"payments\" of \"prod-eks\" for a while",
+	ValidArgsFunction: completeSwitchTarget,
+	Run: func(cmd *cobra.Command, args []string) {
+		flagNamespace := getStringFlag(cmd, "namespace")
+
+		// Load kubeconfig from file
+		confPath := os.Getenv("KUBECONFIG")
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
+		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+
+		ctxName, aliasNs := resolveContextOrAlias(conf, args[0], getBoolFlag(cmd, "exact"))
+		if flagNamespace == "" {
+			flagNamespace = aliasNs
+		}
+
+		if contextProtected(ctxName) {
+			guardProtected(ctxName, "start a shell in it", getBoolFlag(cmd, "yes"))
+		}
+
+		// Write the private copy with the context selected
+		conf.CurrentContext = ctxName
+		if flagNamespace != "" {
+			conf.Contexts[ctxName].Namespace = flagNamespace
+		}
+
+		tmp, err := os.CreateTemp("", "ks-shell-*.yaml")
+		handleFatalf(err, "Error creating private kubeconfig: %v", err)
+		tmp.Close()
+		privatePath := tmp.Name()
+
+		err = writeKubeconfig(privatePath, conf)
+		if err != nil {
+			os.Remove(privatePath)
+			fatalf("Error writing private kubeconfig: %v", err)
+		}
+
+		level, _ := strconv.Atoi(os.Getenv("KS_SHELL_LEVEL"))
+		infof(`Starting shell in context "%s" (namespace: "%s"). Exit the shell to return.`, ctxName,
+			conf.Contexts[ctxName].Namespace)
+
+		code := runWithKubeconfig(privatePath, []string{userShell()},
+			"KS_SHELL="+ctxName,
+			"KS_SHELL_LEVEL="+strconv.Itoa(level+1),
+			"KS_SHELL_KUBECONFIG="+privatePath,
+			// Session files belong to the parent shell
+			"KS_SESSION=",
+		)
+		os.Remove(privatePath)
+
+		infof("Left shell in context %s.", ctxName)
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.Flags().StringP("namespace", "n", "", "The namespace to use in the context")
+	shellCmd.Flags().Bool("exact", false, "Only accept the exact context name")
+	shellCmd.Flags().BoolP("yes", "y", false, "Start shells in protected contexts without asking for confirmation")
+	_ = shellCmd.RegisterFlagCompletionFunc("namespace", completeNamespaces(true))
+}
+
+// userShell returns the path of the user's shell.
+func userShell() string {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+	if runtime.GOOS == "windows" {
+		if comspec := os.Getenv("COMSPEC"); comspec != "" {
+			return comspec
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+}
+
+// shellConfigPath returns the path of the private kubeconfig if running inside "ks shell", or an empty string
+// otherwise.
+func shellConfigPath() string {
+	path := os.Getenv("KS_SHELL_KUBECONFIG")
+	if path == "" || kubeconfigTarget(os.Getenv("KUBECONFIG")) != path {
+		return ""
+	}
+	return path
+}
+
+// sharedKubeconfigTarget returns the path of the kubeconfig that holds the current context shared by all shells. Inside
+// "ks shell", this is the master config rather than the private copy.
+func sharedKubeconfigTarget() string {
+	if shellConfigPath() != "" {
+		return masterConfigPath
+	}
+	return kubeconfigTarget(os.Getenv("KUBECONFIG"))
+}
@@ -2,38 +2,105 @@ package cmd
 
 import (
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 const example = `
   ks switch my-context -n my-namespace  # switch to context "my-context" with namespace "my-namespace"
   ks switch my-other-context	        # switch to context "my-other-context" with default/existing namespace
   ks switch -n my-namespace             # use "my-namespace" in the current context
+  ks switch prod                        # switch to the only context whose name starts with or contains "prod"
+  ks switch                             # pick a context and namespace interactively
+  ks switch -                           # go back to the previous context and namespace
+  ks switch @3                          # go back to entry 3 of "ks history"
+  ks switch pay                         # switch to the context and namespace of alias "pay"
 `
 
 // switchCmd represents the switch command
 var switchCmd = &cobra.Command{
-	Use:     "switch [name]",
-	Aliases: []string{"s"},
-	Args:    cobra.MaximumNArgs(1),
-	Short:   "Switch to a different context",
+	Use:               "switch [name]",
+	Aliases:           []string{"s"},
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSwitchTarget,
+	Short:             "Switch to a different context",
 	Long: `Switch to a different context and/or namespace in one of the kubeconfig files under KSPATH.
+
+If neither a context nor a namespace is given and ks is running in a terminal, an interactive picker is shown. Type to
+filter contexts, use the arrow keys to move, enter to choose and escape to cancel. After choosing a context, a
+namespace can be chosen the same way, or escape can be pressed to keep the context's current namespace.
+
+The context does not have to be given by its exact name. A unique prefix, substring or fuzzy match of the name works
+too, in that order of preference. If a name is ambiguous, the candidates are listed instead. Use --exact to turn this
+off, e.g. in scripts.
+
+When a new namespace is chosen, ks checks that it exists in the context's cluster and refuses to switch if it doesn't.
+Use --create to create it instead. If the cluster can't be reached or doesn't allow the check, only a warning is shown.
+
+Every switch is recorded in ${HOME}/.ks/history.yaml. Use "-" as the context to go back to the previous context and
+namespace, or "@<n>" to go back to entry n of "ks history". Aliases created with "ks alias add" can be used in place of
+a context name, and select the alias's namespace too unless -n is given.
+
+Contexts can be marked as protected in ${HOME}/.ks/settings.yaml, by name pattern or by label selector. Switching to a
+protected context asks for confirmation unless --yes is given, and can be set to switch back to the previous context
+automatically after a while. The switch back happens the next time ks runs after that time, e.g. from "ks prompt".
+
+After switching, ks warns if the client certificate, token or cluster CA certificate of the context has expired or is
+about to (see "ks expiry").
+
+In session mode (see "ks activate --session"), the change only applies to the current shell.
 `,
 	Example: strings.TrimLeft(example, "\n"),
 	Run: func(cmd *cobra.Command, args []string) {
 		flagNamespace := getStringFlag(cmd, "namespace")
+		flagExact := getBoolFlag(cmd, "exact")
+		flagCreate := getBoolFlag(cmd, "create")
+		flagYes := getBoolFlag(cmd, "yes")
 
 		// Load kubeconfig from file
 		confPath := os.Getenv("KUBECONFIG")
-		conf, err := loadKubeconfig([]string{confPath})
+		conf, err := loadKubeconfig(splitKubeconfig(confPath))
 		handleFatalf(err, "Error loading config from %s: %v", confPath, err)
 
-		// Get context to switch to, defaulting to current context if one was not specified
+		// Get context to switch to, defaulting to current context if one was not specified. If neither a context nor a
+		// namespace was specified, let the user pick them interactively if we're in a terminal.
 		ctxName := conf.CurrentContext
+		var prevNs string
+		if ctx, ok := conf.Contexts[ctxName]; ok {
+			prevNs = ctx.Namespace
+		}
+		prevCtxName := ctxName
+		fromHistory := false
+
 		if len(args) > 0 {
-			ctxName = args[0]
+			// "-" and "@<n>" refer to the history, anything else is an alias or a context name
+			entry, ok, err := lookupHistory(args[0], prevCtxName, prevNs)
+			handleFatalf(err, "Error reading history: %v", err)
+
+			if ok {
+				ctxName = entry.Context
+				if flagNamespace == "" {
+					// The namespace was fine last time, so don't bother checking it again
+					flagNamespace = entry.Namespace
+					fromHistory = true
+				}
+			} else {
+				var aliasNs string
+				ctxName, aliasNs = resolveContextOrAlias(conf, args[0], flagExact)
+				if flagNamespace == "" {
+					flagNamespace = aliasNs
+				}
+			}
+		} else if flagNamespace == "" && isInteractive() && len(conf.Contexts) > 0 {
+			var ok bool
+			ctxName, flagNamespace, ok = pickContext(conf)
+			if !ok {
+				infof("Cancelled.")
+				return
+			}
 		}
 
 		ctx, ok := conf.Contexts[ctxName]
@@ -41,20 +108,119 @@ var switchCmd = &cobra.Command{
 			fatalf("No such context: %s", ctxName)
 		}
 
-		// Set current context and namespace, if specified
-		conf.CurrentContext = ctxName
+		// Make sure the user knows what they're doing before entering a protected context
+		protected := contextProtected(ctxName)
+		if protected && ctxName != prevCtxName {
+			guardProtected(ctxName, "switch to it", flagYes)
+		}
+
+		// Make sure a newly chosen namespace actually exists
+		if flagNamespace != "" && flagNamespace != ctx.Namespace && !fromHistory {
+			verifyNamespace(conf, ctxName, flagNamespace, flagCreate)
+		}
+
+		// Set current context and namespace, if specified, and write updated config to file
 		if flagNamespace != "" {
 			ctx.Namespace = flagNamespace
 		}
-
-		// Write updated config to file
-		err = writeKubeconfig(masterConfigPath, conf)
+		err = writeCurrentContext(conf, ctxName, ctx)
 		handleFatalf(err, "Error writing config: %v", err)
+
+		// A broken history shouldn't stop anyone from switching, so only warn about it
+		if err = recordSwitch(prevCtxName, prevNs, ctxName, ctx.Namespace); err != nil {
+			warnf("Error recording history: %v", err)
+		}
+
 		infof(`Switched to context "%s" (namespace: "%s")`, ctxName, ctx.Namespace)
+		warnExpiring(conf, ctxName)
+
+		if protected {
+			infof("%s", protectedBanner(ctxName))
+			if ctxName != prevCtxName {
+				if err = scheduleRevert(ctxName, prevCtxName, prevNs); err != nil {
+					warnf("Error scheduling switch back to %s: %v", prevCtxName, err)
+				}
+			}
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(switchCmd)
 	switchCmd.Flags().StringP("namespace", "n", "", "The namespace to use in the context")
+	switchCmd.Flags().Bool("exact", false, "Only accept the exact context name")
+	switchCmd.Flags().Bool("create", false, "Create the namespace if it does not exist")
+	switchCmd.Flags().BoolP("yes", "y", false, "Switch to protected contexts without asking for confirmation")
+	_ = switchCmd.RegisterFlagCompletionFunc("namespace", completeNamespaces(true))
+}
+
+// pickContext lets the user interactively choose a context from the given config and then a namespace for it. The
+// namespace is empty if the user chose to keep the context's current namespace. ok is false if the user cancelled.
+func pickContext(conf *api.Config) (ctxName string, namespace string, ok bool) {
+	origins := loadOrigins()
+	ctxName, ok, err := pick("Context", sortedKeys(conf.Contexts), func(name string) []string {
+		return describeContext(conf, name, origins)
+	}, false)
+	handleFatalf(err, "Error choosing context: %v", err)
+	if !ok {
+		return "", "", false
+	}
+
+	namespace, ok, err = pick("Namespace", knownNamespaces(conf, ctxName), nil, true)
+	handleFatalf(err, "Error choosing namespace: %v", err)
+	if !ok {
+		// Keep the current namespace
+		return ctxName, "", true
+	}
+
+	return ctxName, namespace, true
+}
+
+// describeContext returns lines describing the context with the given name.
+func describeContext(conf *api.Config, name string, origins map[string]string) []string {
+	ctx := conf.Contexts[name]
+
+	var server string
+	if cluster, ok := conf.Clusters[ctx.Cluster]; ok {
+		server = cluster.Server
+	}
+
+	source := origins[name]
+	if source == "" {
+		source = ctx.LocationOfOrigin
+	}
+
+	return []string{
+		"Cluster:   " + ctx.Cluster,
+		"Server:    " + server,
+		"User:      " + ctx.AuthInfo,
+		"Namespace: " + ctx.Namespace,
+		"Source:    " + source,
+	}
+}
+
+// knownNamespaces returns the namespaces used by contexts in the given config, with the namespace of the context with
+// the given name first.
+func knownNamespaces(conf *api.Config, ctxName string) []string {
+	seen := map[string]bool{"": true}
+	namespaces := make([]string, 0)
+	if ctx, ok := conf.Contexts[ctxName]; ok && ctx.Namespace != "" {
+		namespaces = append(namespaces, ctx.Namespace)
+		seen[ctx.Namespace] = true
+	}
+
+	others := []string{"default"}
+	for _, ctx := range conf.Contexts {
+		others = append(others, ctx.Namespace)
+	}
+	sort.Strings(others)
+
+	for _, ns := range others {
+		if !seen[ns] {
+			namespaces = append(namespaces, ns)
+			seen[ns] = true
+		}
+	}
+
+	return namespaces
 }
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// uninitCmd represents the uninit command
+var uninitCmd = &cobra.Command{
+	Use:               "uninit",
+	Args:              cobra.ExactArgs(0),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	Short:             "Undo ks init",
+	Long: `This command removes all initialization code added by "ks init" from the rc files of all supported shells
+(.bashrc, .zshrc, .profile, .kshrc, config.fish, config.nu), along with the activation scripts in ${HOME}/.ks.
+
+The rest of ${HOME}/.ks is kept unless --purge is used. Use --dry-run to see what would be removed without changing
+anything.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flagDryRun := getBoolFlag(cmd, "dry-run")
+		flagPurge := getBoolFlag(cmd, "purge")
+
+		// Several shells can share an rc file, so make sure each one is only handled once
+		seen := make(map[string]bool)
+		changed := 0
+		for _, name := range shellNames() {
+			path := shells[name].rcPath()
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			contents, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			handleFatalf(err, "Error reading %s: %v", path, err)
+
+			lines := strings.Split(string(contents), "\n")
+			removed := findInitBlocks(lines)
+			if len(removed) == 0 {
+				continue
+			}
+			changed++
+
+			if flagDryRun {
+				// Print a diff-like preview of the lines that would be removed
+				infof("--- %s", path)
+				for i, line := range lines {
+					if removed[i] {
+						infof("-%5d: %s", i+1, line)
+					}
+				}
+				continue
+			}
+
+			kept := make([]string, 0, len(lines))
+			for i, line := range lines {
+				if !removed[i] {
+					kept = append(kept, line)
+				}
+			}
+
+			info, err := os.Stat(path)
+			handleFatalf(err, "Error checking %s: %v", path, err)
+			err = os.WriteFile(path, []byte(strings.Join(kept, "\n")), info.Mode().Perm())
+			handleFatalf(err, "Error writing %s: %v", path, err)
+			infof("Removed initialization code from %s.", path)
+		}
+
+		if flagDryRun {
+			if changed == 0 {
+				infof("No initialization code found.")
+			}
+			if flagPurge {
+				infof("Would remove %s.", ksHomeDir)
+			}
+			return
+		}
+
+		if changed == 0 {
+			infof("No initialization code found.")
+		}
+
+		// The activation scripts are useless without the initialization code
+		for _, name := range shellNames() {
+			err := os.RemoveAll(shells[name].initScriptPath())
+			handleFatalf(err, "Error removing %s: %v", shells[name].initScriptPath(), err)
+		}
+
+		if flagPurge {
+			err := os.RemoveAll(ksHomeDir)
+			handleFatalf(err, "Error removing %s: %v", ksHomeDir, err)
+			infof("Removed %s.", ksHomeDir)
+		}
+
+		// Let the user know their current shell is still using ks-managed config
+		if os.Getenv("KS_ACTIVE") != "" {
+			warnf(`KUBECONFIG is still set to %s in this shell session.`, os.Getenv("KUBECONFIG"))
+			infof(`Run 'eval "$(ks env --deactivate)"' to restore your previous KUBECONFIG.`)
+		} else if isMasterConfig(kubeconfigTarget(os.Getenv("KUBECONFIG"))) {
+			warnf(`KUBECONFIG is still set to %s in this shell session.`, os.Getenv("KUBECONFIG"))
+			infof(`Run "unset KUBECONFIG" or start a new shell session to go back to your regular KUBECONFIG.`)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uninitCmd)
+	uninitCmd.Flags().Bool("dry-run", false, "Show what would be removed without changing anything")
+	uninitCmd.Flags().Bool("purge", false, "Also remove ${HOME}/.ks")
+}
+
+// findInitBlocks returns the indices of all lines that belong to blocks added by ks init. Each block consists of the
+// marker line, the line after it that sources a ks activation script, and the blank line before it if there is one.
+// Markers that aren't followed by such a line have been edited by hand, so they are left alone.
+func findInitBlocks(lines []string) map[int]bool {
+	removed := make(map[int]bool)
+	for i, line := range lines {
+		if strings.TrimSpace(line) != rcMarker {
+			continue
+		}
+		if i+1 >= len(lines) || !strings.Contains(lines[i+1], ".ks/init.") {
+			continue
+		}
+
+		removed[i] = true
+		removed[i+1] = true
+		if i > 0 && strings.TrimSpace(lines[i-1]) == "" && !removed[i-1] {
+			removed[i-1] = true
+		}
+	}
+	return removed
+}
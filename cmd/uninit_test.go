@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindInitBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{
+			name:     "single block",
+			contents: "export EDITOR=vim\n\n" + rcMarker + "\n" + posixSnippet + "\n",
+			want:     "export EDITOR=vim\n",
+		},
+		{
+			name: "repeated blocks",
+			contents: "export EDITOR=vim\n\n" + rcMarker + "\n" + posixSnippet + "\n\n" + rcMarker + "\n" + posixSnippet +
+				"\nalias k=kubectl\n",
+			want: "export EDITOR=vim\nalias k=kubectl\n",
+		},
+		{
+			name:     "block at the top without blank line",
+			contents: rcMarker + "\n" + posixSnippet + "\nexport EDITOR=vim\n",
+			want:     "export EDITOR=vim\n",
+		},
+		{
+			name:     "indented marker",
+			contents: "if true; then\n  " + rcMarker + "\n  " + posixSnippet + "\nfi\n",
+			want:     "if true; then\nfi\n",
+		},
+		{
+			name:     "fish",
+			contents: "set -x EDITOR vim\n\n" + rcMarker + "\n" + shells["fish"].rcSnippet + "\n",
+			want:     "set -x EDITOR vim\n",
+		},
+		{
+			name:     "marker at the end of the file",
+			contents: "export EDITOR=vim\n\n" + rcMarker,
+			want:     "export EDITOR=vim\n\n" + rcMarker,
+		},
+		{
+			name:     "marker without activation script",
+			contents: "export EDITOR=vim\n\n" + rcMarker + "\nexport KUBECONFIG=~/.kube/config\n",
+			want:     "export EDITOR=vim\n\n" + rcMarker + "\nexport KUBECONFIG=~/.kube/config\n",
+		},
+		{
+			name:     "no ks block",
+			contents: "export EDITOR=vim\n# source ~/.ks/init.sh by hand\n. ~/.ks/init.sh\n",
+			want:     "export EDITOR=vim\n# source ~/.ks/init.sh by hand\n. ~/.ks/init.sh\n",
+		},
+		{
+			name:     "empty file",
+			contents: "",
+			want:     "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Remove lines the same way ks uninit does
+			lines := strings.Split(test.contents, "\n")
+			removed := findInitBlocks(lines)
+			kept := make([]string, 0, len(lines))
+			for i, line := range lines {
+				if !removed[i] {
+					kept = append(kept, line)
+				}
+			}
+
+			if got := strings.Join(kept, "\n"); got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+			if test.contents == test.want && len(removed) > 0 {
+				t.Errorf("expected no lines to be removed, got %v", removed)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +15,9 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// messageOut is where info, warning and error messages are written.
+var messageOut io.Writer = os.Stdout
+
 // handleFatalf prints the given message and exits with code 1 if the error is not nil. Otherwise, it does nothing.
 func handleFatalf(err error, format string, a ...any) {
 	if err != nil {
@@ -29,17 +33,22 @@ func fatalf(format string, a ...any) {
 
 // infof prints an info message.
 func infof(format string, a ...any) {
-	fmt.Printf(format+"\n", a...)
+	fmt.Fprintf(messageOut, format+"\n", a...)
 }
 
 // warnf prints an warning message.
 func warnf(format string, a ...any) {
-	fmt.Printf("WARNING: "+format+"\n", a...)
+	fmt.Fprintf(messageOut, "WARNING: "+format+"\n", a...)
+}
+
+// errorf prints an error message without exiting.
+func errorf(format string, a ...any) {
+	fmt.Fprintf(messageOut, "ERROR: "+format+"\n", a...)
 }
 
-// printCtx prints information about a context.
+// printCtx prints information about a context. Only the name is printed if the context doesn't exist.
 func printCtx(name string, ctx *api.Context, verbose bool) {
-	if verbose {
+	if verbose && ctx != nil {
 		infof(
 			"%s\n  Location: %s\n  Cluster: %s\n  Namespace: %s\n",
 			name,
@@ -52,64 +61,163 @@ func printCtx(name string, ctx *api.Context, verbose bool) {
 	}
 }
 
-// loadKubeconfig loads all kubeconfig files at the given paths (can be files or dirs).
-func loadKubeconfig(paths []string) (*api.Config, error) {
-	// Search for kubeconfig files in each path
-	loadingRules := clientcmd.ClientConfigLoadingRules{
-		Precedence: make([]string, 0),
+// expandHome replaces a leading "~" in the given path with the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir, path[2:])
 	}
+	return path
+}
+
+// splitKubeconfig splits the given value of the KUBECONFIG env var into the paths it lists, omitting any that don't
+// exist.
+func splitKubeconfig(value string) []string {
+	paths := make([]string, 0)
+	for _, path := range filepath.SplitList(value) {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// kubeconfigTarget returns the kubeconfig file that commands should edit, given the value of the KUBECONFIG env var.
+// If it lists several files (e.g. in session mode), the master config is preferred, otherwise the first file is used.
+func kubeconfigTarget(value string) string {
+	paths := filepath.SplitList(value)
 	for _, path := range paths {
-		// Replace "~" with home directory in path
-		if strings.HasPrefix(path, "~/") {
-			path = filepath.Join(homeDir, path[2:])
+		if isMasterConfig(path) {
+			return path
 		}
+	}
+
+	if len(paths) > 0 {
+		return paths[0]
+	}
+	return ""
+}
 
+// loadKubeconfig loads all kubeconfig files at the given paths (can be files or dirs).
+func loadKubeconfig(paths []string) (*api.Config, error) {
+	files, err := findKubeconfigFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadKubeconfigFiles(files, &settings{})
+}
+
+// findKubeconfigFiles returns the paths of all valid kubeconfig files at the given paths (can be files or dirs).
+func findKubeconfigFiles(paths []string) ([]string, error) {
+	files := make([]string, 0)
+	err := walkKubeconfigFiles(paths, func(path string, _ *api.Config) error {
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// walkKubeconfigFiles calls fn with every valid kubeconfig file found at the given paths (can be files or dirs) in
+// order of loading precedence.
+func walkKubeconfigFiles(paths []string, fn func(path string, conf *api.Config) error) error {
+	for _, path := range paths {
 		// Find all kubeconfig files in path
-		if err := filepath.Walk(path, func(currentPath string, info os.FileInfo, err error) error {
+		if err := filepath.Walk(expandHome(path), func(currentPath string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
 
-			if !info.IsDir() {
-				// Check if this is a valid kubeconfig file by loading it
-				_, err = clientcmd.LoadFromFile(currentPath)
-				if err != nil {
-					return nil
-				}
+			if info.IsDir() {
+				return nil
+			}
 
-				loadingRules.Precedence = append(loadingRules.Precedence, currentPath)
+			// Check if this is a valid kubeconfig file by loading it
+			conf, err := clientcmd.LoadFromFile(currentPath)
+			if err != nil {
+				// We'll assume an error means this is not a valid kubeconfig file
+				return nil
 			}
 
-			return nil
+			return fn(currentPath, conf)
 		}); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	// Load all the located kubeconfig files
-	return loadingRules.Load()
+	return nil
 }
 
 // writeKubeconfig writes the given kubeconfig to a file at the given path.
 func writeKubeconfig(path string, conf *api.Config) error {
-	// Encode and write to file
+	output, err := encodeKubeconfig(conf)
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(path, output, 0600); err != nil {
+		return fmt.Errorf("error writing merged kubeconfig: %v", err)
+	}
+
+	// The master config is an input to the next merge too, but changes made by ks itself don't require one
+	if isMasterConfig(path) {
+		if err = recordOwnWrite(path); err != nil {
+			return fmt.Errorf("error updating manifest: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// encodeKubeconfig encodes the given kubeconfig as YAML.
+func encodeKubeconfig(conf *api.Config) ([]byte, error) {
 	jsonBytes, err := runtime.Encode(latest.Codec, conf)
 	if err != nil {
-		return fmt.Errorf("error encoding merged kubeconfig as JSON: %v", err)
+		return nil, fmt.Errorf("error encoding merged kubeconfig as JSON: %v", err)
 	}
 
 	output, err := yaml.JSONToYAML(jsonBytes)
 	if err != nil {
-		return fmt.Errorf("error converting merged JSON kubeconfig to YAML: %v", err)
+		return nil, fmt.Errorf("error converting merged JSON kubeconfig to YAML: %v", err)
 	}
+	return output, nil
+}
 
-	if err = os.WriteFile(path, output, 0600); err != nil {
-		return fmt.Errorf("error writing merged kubeconfig: %v", err)
+// readYAMLFile decodes the YAML file at the given path into v. A missing file is not an error and leaves v unchanged.
+func readYAMLFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	if err = yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding %s: %v", path, err)
 	}
 
 	return nil
 }
 
+// writeYAMLFile encodes v as YAML and writes it to a file at the given path.
+func writeYAMLFile(path string, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %v", path, err)
+	}
+
+	if err = os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// isMasterConfig returns true if the given path refers to the kubeconfig file managed by ks.
+func isMasterConfig(path string) bool {
+	return filepath.Clean(path) == filepath.Clean(masterConfigPath)
+}
+
 // getStringFlag returns the string value from the flag of the given name from the given command, or logs a fatal error.
 func getStringFlag(cmd *cobra.Command, name string) string {
 	flag, err := cmd.Flags().GetString(name)
@@ -3,66 +3,120 @@ package cmd
 import (
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 // whenceCmd represents the whence command
 var whenceCmd = &cobra.Command{
-	Use:     "whence [context]",
-	Args:    cobra.MaximumNArgs(1),
-	Aliases: []string{"w"},
-	Short:   "List kubeconfig files in which contexts exist",
+	Use:               "whence [context]",
+	Args:              cobra.MaximumNArgs(1),
+	Aliases:           []string{"w"},
+	ValidArgsFunction: completeFirstContext,
+	Short:             "List kubeconfig files in which contexts exist",
 	Long: `This command prints the locations and contexts of all kubeconfig files found under KSPATH in order of loading 
-precedence. If a context argument is provided, only paths in which that context exists will be printed.
+precedence. If a context argument is provided, only paths in which that context exists will be printed. Contexts
+renamed by naming rules from ${HOME}/.ks/settings.yaml are listed along with their original names.
+
+With -o, every definition of a context is listed as it appears in its file, so "cluster" and "user" are the names used
+in that file, before ks renames clusters and users that collide with others during the merge. Only the definition that
+wins the merge is marked as current, and "ks list -o json" shows the merged names.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
-		for _, path := range kubeconfigPaths {
-			// Replace "~" with home directory in path
-			if strings.HasPrefix(path, "~/") {
-				path = filepath.Join(homeDir, path[2:])
+		printer, err := getOutputPrinter(cmd)
+		handleFatalf(err, "Invalid --output: %v", err)
+
+		s, err := loadSettings()
+		handleFatalf(err, "Error loading settings: %v", err)
+
+		// Machine-readable output lists every definition of a context, marking the one that won the merge if it is
+		// the current context
+		var (
+			currentCtxName string
+			origins        map[string]string
+			infos          = make([]contextInfo, 0)
+		)
+		if printer != nil {
+			confPath := os.Getenv("KUBECONFIG")
+			merged, err := loadKubeconfig(splitKubeconfig(confPath))
+			handleFatalf(err, "Error loading config from %s: %v", confPath, err)
+			currentCtxName = merged.CurrentContext
+			origins = loadOrigins()
+		}
+
+		err = walkKubeconfigFiles(kubeconfigPaths, func(currentPath string, conf *api.Config) error {
+			// Show contexts by the names they are given during the merge
+			originals, err := applyNamingRules(conf, currentPath, s)
+			if err != nil {
+				return err
 			}
 
-			// Find all kubeconfig files in path
-			err := filepath.Walk(path, func(currentPath string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
+			if printer != nil {
+				conf.CurrentContext = ""
+				if filepath.Clean(origins[currentCtxName]) == filepath.Clean(currentPath) {
+					conf.CurrentContext = currentCtxName
 				}
-
-				if !info.IsDir() {
-					// Check if this is a valid kubeconfig file by loading it
-					conf, err := clientcmd.LoadFromFile(currentPath)
-					if err != nil {
-						// We'll assume an error means this is not a valid kubeconfig file
-						return nil
+				for _, ctxName := range sortedKeys(conf.Contexts) {
+					if len(args) == 0 || ctxName == args[0] || originals[ctxName] == args[0] {
+						infos = append(infos, newContextInfo(conf, ctxName, nil))
 					}
+				}
+				return nil
+			}
 
-					var currentMsg string
-					if currentPath == os.Getenv("KUBECONFIG") {
-						currentMsg = " (current)"
-					}
+			var currentMsg string
+			if isInKubeconfig(currentPath) {
+				currentMsg = " (current)"
+			}
 
-					// Print contexts from the file if no specific context was listed. Otherwise, only print the name
-					// of the file if it contains the given context.
-					if len(args) == 0 {
-						infof(currentPath + currentMsg)
-						for ctxName, _ := range conf.Contexts {
-							infof("  %s", ctxName)
-						}
-					} else if conf.Contexts[args[0]] != nil {
-						infof(currentPath + currentMsg)
+			// Print contexts from the file if no specific context was listed. Otherwise, only print the name
+			// of the file if it contains the given context.
+			if len(args) == 0 {
+				infof(currentPath + currentMsg)
+				for ctxName, _ := range conf.Contexts {
+					if original, ok := originals[ctxName]; ok {
+						infof("  %s (originally %s)", ctxName, original)
+					} else {
+						infof("  %s", ctxName)
 					}
 				}
+			} else if conf.Contexts[args[0]] != nil || hasOriginal(originals, args[0]) {
+				infof(currentPath + currentMsg)
+			}
 
-				return nil
-			})
-			handleFatalf(err, "Error loading config: %v", err)
+			return nil
+		})
+		handleFatalf(err, "Error loading config: %v", err)
+
+		if printer != nil {
+			err = printer.print(infos, false)
+			handleFatalf(err, "Error printing contexts: %v", err)
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(whenceCmd)
+	addOutputFlag(whenceCmd)
+}
+
+// hasOriginal returns true if any renamed context had the given original name.
+func hasOriginal(originals map[string]string, name string) bool {
+	for _, original := range originals {
+		if original == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isInKubeconfig returns true if the given path is listed in the KUBECONFIG env var.
+func isInKubeconfig(path string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if filepath.Clean(p) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
 }